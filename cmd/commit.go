@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"html"
 	"os"
 	"path"
 	"strconv"
@@ -70,11 +69,15 @@ var commitCmd = &cobra.Command{
 			return err
 		}
 
-		g := git.New(
+		g, err := git.New(
 			git.WithDiffUnified(viper.GetInt("git.diff_unified")),
 			git.WithExcludeList(viper.GetStringSlice("git.exclude_list")),
 			git.WithEnableAmend(commitAmend),
 		)
+		if err != nil {
+			return err
+		}
+
 		diff, err := g.DiffFiles()
 		if err != nil {
 			return err
@@ -294,9 +297,6 @@ var commitCmd = &cobra.Command{
 			commitMessage = resp.Content
 		}
 
-		// unescape html entities in commit message
-		commitMessage = html.UnescapeString(commitMessage)
-
 		// Output commit summary data from AI
 		color.Yellow("================Commit Summary====================")
 		color.Yellow("\n" + strings.TrimSpace(commitMessage) + "\n\n")
@@ -308,7 +308,7 @@ var commitCmd = &cobra.Command{
 			if err != nil {
 				return err
 			}
-			outputFile = path.Join(strings.TrimSpace(out), "COMMIT_EDITMSG")
+			outputFile = path.Join(out, "COMMIT_EDITMSG")
 		}
 		color.Cyan("Write the commit message to " + outputFile + " file")
 		// write commit message to git staging file
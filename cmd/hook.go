@@ -9,6 +9,16 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	forceHook  bool
+	appendHook bool
+)
+
+func init() {
+	hookCmd.Flags().BoolVar(&forceHook, "force", false, "overwrite an existing hook previously installed by zcode")
+	hookCmd.Flags().BoolVar(&appendHook, "append", false, "append to an existing prepare-commit-msg hook instead of failing")
+}
+
 var hookCmd = &cobra.Command{
 	Use:   "hook",
 	Short: "install/uninstall git prepare-commit-msg hook",
@@ -18,11 +28,20 @@ var hookCmd = &cobra.Command{
 			return errors.New("only support install or uninstall command")
 		}
 
-		g := git.New()
+		g, err := git.New(
+			git.WithForceHook(forceHook),
+		)
+		if err != nil {
+			return err
+		}
 
 		switch args[0] {
 		case "install":
-			if err := g.InstallHook(); err != nil {
+			install := g.InstallHook
+			if appendHook {
+				install = g.InstallHookAppend
+			}
+			if err := install(); err != nil {
 				return err
 			}
 			color.Green("Install git hook: prepare-commit-msg successfully")
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"strconv"
 	"strings"
 
@@ -24,7 +25,7 @@ func init() {
 	reviewCmd.Flags().StringVar(&commitModel, "model", openai.DefaultModel, "select openai model")
 	reviewCmd.Flags().StringVar(&commitLang, "lang", "en", "summarizing language uses English by default")
 	reviewCmd.Flags().StringSliceVar(&excludeList, "exclude_list", []string{}, "exclude file from git diff command")
-	reviewCmd.Flags().BoolVar(&commitAmend, "amend", false, "replace the tip of the current branch by creating a new commit.")
+	reviewCmd.Flags().BoolVar(&commitAmend, "amend", false, "review the changes of the last commit")
 	reviewCmd.Flags().StringVar(&diffTagPrefix, "diff_tag_prefix", "", "review latest two tags commit changes diff")
 	reviewCmd.Flags().StringVar(&commitId, "commit_id", "", "review commit changes diff")
 	reviewCmd.Flags().StringSliceVar(&diffList, "diff", []string{}, "review two tags or branch diff")
@@ -38,14 +39,29 @@ var reviewCmd = &cobra.Command{
 			return err
 		}
 
-		g := git.New(
+		// --diff names the two refs to compare; a single ref is compared with HEAD.
+		if len(diffList) > 2 {
+			return errors.New("--diff takes at most two refs")
+		}
+		var diffFrom, diffTo string
+		if len(diffList) > 0 {
+			diffFrom = diffList[0]
+		}
+		if len(diffList) > 1 {
+			diffTo = diffList[1]
+		}
+
+		g, err := git.New(
 			git.WithDiffUnified(viper.GetInt("git.diff_unified")),
 			git.WithExcludeList(viper.GetStringSlice("git.exclude_list")),
-			git.WithEnableAmend(commitAmend),
+			git.WithLastCommit(commitAmend),
 			git.WithDiffTagPrefix(diffTagPrefix),
-			git.WithDiffList(diffList),
+			git.WithCommitRange(diffFrom, diffTo),
 			git.WithCommitId(commitId),
 		)
+		if err != nil {
+			return err
+		}
 
 		diff, err := g.DiffFiles()
 		if err != nil {
@@ -0,0 +1,81 @@
+package git
+
+import (
+	"context"
+)
+
+// Backend selects how ChangedFiles and DiffFiles compute diffs.
+type Backend string
+
+const (
+	// BackendExec runs the git binary. It supports every option.
+	BackendExec Backend = "exec"
+	// BackendGoGit computes diffs in pure Go with go-git, for environments without a git binary.
+	// It supports the working tree, WithCommitRange, WithTagToHead, WithCommitId and WithEnableAmend.
+	// It cannot ignore whitespace, so DiffFiles needs WithWhitespaceMode("none"), and options it cannot
+	// honor, such as WithDetectRenames or WithWordDiff, make diffs fail instead of being ignored.
+	BackendGoGit Backend = "go-git"
+)
+
+// diffBackend computes the changes selected by a Command.
+type diffBackend interface {
+	changedFiles(ctx context.Context) ([]string, error)
+	diff(ctx context.Context) (string, error)
+}
+
+var (
+	_ diffBackend = execDiffer{}
+	_ diffBackend = goGitDiffer{}
+)
+
+// execDiffer computes diffs by running the git binary.
+type execDiffer struct {
+	c *Command
+}
+
+func (d execDiffer) changedFiles(ctx context.Context) ([]string, error) {
+	output, err := d.c.retry(ctx, func() ([]byte, error) {
+		cmd, err := d.c.diffNames(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return d.c.diffOutput(cmd)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	files := splitNUL(string(output))
+	if d.c.nameStatus {
+		changes, err := parseNameStatus(files)
+		if err != nil {
+			return nil, err
+		}
+		files = make([]string, len(changes))
+		for i, change := range changes {
+			files[i] = change.Path
+		}
+	}
+	if d.c.stashRef != "" {
+		files = d.c.selectPaths(files)
+	}
+	return files, nil
+}
+
+func (d execDiffer) diff(ctx context.Context) (string, error) {
+	output, err := d.c.retry(ctx, func() ([]byte, error) {
+		cmd, err := d.c.diffFiles(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return d.c.diffOutput(cmd)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if d.c.stashRef != "" {
+		return filterPatches(string(output), d.c.selectsPath), nil
+	}
+	return string(output), nil
+}
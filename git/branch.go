@@ -0,0 +1,40 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrNoDefaultBranch is returned by DefaultBranch when neither origin/HEAD nor a main or master branch exists.
+var ErrNoDefaultBranch = errors.New("cannot detect the default branch")
+
+// defaultBranchCandidates are the local branches DefaultBranch falls back to, in order.
+var defaultBranchCandidates = []string{"main", "master"}
+
+// DefaultBranch returns the name of the repository's default branch, such as "main",
+// for diffing a feature branch since it branched off with WithBranches.
+// It is read from origin/HEAD, which git clone sets, and otherwise is the first of main and master
+// that exists locally. The name has no remote prefix.
+func (c *Command) DefaultBranch() (string, error) {
+	ctx := context.Background()
+	output, err := c.output(c.gitCmd(
+		ctx,
+		"symbolic-ref",
+		"--quiet",
+		"--short",
+		"refs/remotes/origin/HEAD",
+	))
+	if err == nil {
+		if name := strings.TrimPrefix(strings.TrimSpace(string(output)), "origin/"); name != "" {
+			return name, nil
+		}
+	}
+
+	for _, name := range defaultBranchCandidates {
+		if c.verifyRef(ctx, "refs/heads/"+name) == nil {
+			return name, nil
+		}
+	}
+	return "", ErrNoDefaultBranch
+}
@@ -0,0 +1,39 @@
+package git
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDefaultBranch(t *testing.T) {
+	setupRepo(t)
+	if _, err := mustNew(t).DefaultBranch(); !errors.Is(err, ErrNoDefaultBranch) {
+		t.Errorf("DefaultBranch() without commits error = %v, want %v", err, ErrNoDefaultBranch)
+	}
+
+	commitFile(t, "a.txt", "a\n", "first")
+	runGit(t, "branch", "-M", "master")
+	runGit(t, "branch", "develop")
+	got, err := mustNew(t).DefaultBranch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "master" {
+		t.Errorf("DefaultBranch() = %q, want master", got)
+	}
+
+	runGit(t, "branch", "main")
+	if got, _ := mustNew(t).DefaultBranch(); got != "main" {
+		t.Errorf("DefaultBranch() with main and master = %q, want main", got)
+	}
+
+	runGit(t, "update-ref", "refs/remotes/origin/develop", "HEAD")
+	runGit(t, "symbolic-ref", "refs/remotes/origin/HEAD", "refs/remotes/origin/develop")
+	got, err = mustNew(t).DefaultBranch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "develop" {
+		t.Errorf("DefaultBranch() with origin/HEAD = %q, want develop", got)
+	}
+}
@@ -0,0 +1,383 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+var errorsEmptyMessageFile = errors.New("commit message file is empty")
+
+// ErrNothingToCommit is returned by commits made with WithSkipIfEmpty when nothing is staged.
+var ErrNothingToCommit = errors.New("nothing to commit")
+
+func (c *Command) commit(val string) *gitCommand {
+	val = c.normalize(val)
+	// Amending with an empty message keeps the previous one, e.g. to only add staged files.
+	if c.isAmend && val == "" {
+		return c.commitCmd("--no-edit")
+	}
+	return c.commitCmd(fmt.Sprintf("--message=%s", val))
+}
+
+// normalize converts CRLF line endings in msg to LF and trims trailing whitespace from each line,
+// when normalizeMessage is set.
+func (c *Command) normalize(msg string) string {
+	if !c.normalizeMessage {
+		return msg
+	}
+
+	lines := strings.Split(strings.ReplaceAll(msg, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// commitCmd returns a git commit command with the configured flags and the given message arguments.
+func (c *Command) commitCmd(messageArgs ...string) *gitCommand {
+	args := []string{
+		"commit",
+	}
+
+	if c.noVerify {
+		args = append(args, "--no-verify")
+	}
+
+	if c.signoff {
+		args = append(args, "--signoff")
+	}
+
+	if c.allowEmpty {
+		args = append(args, "--allow-empty")
+	}
+
+	if c.cleanupMode != "" {
+		args = append(args, "--cleanup="+c.cleanupMode)
+	}
+
+	if c.signCommit || c.signingKey != "" {
+		args = append(args, "-S"+c.signingKey)
+	}
+
+	if c.authorName != "" || c.authorEmail != "" {
+		args = append(args, fmt.Sprintf("--author=%s <%s>", c.authorName, c.authorEmail))
+	}
+
+	args = append(args, messageArgs...)
+
+	for _, coAuthor := range c.coAuthors {
+		args = append(args, "--trailer=Co-authored-by: "+coAuthor)
+	}
+
+	keys := make([]string, 0, len(c.trailers))
+	for key := range c.trailers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "--trailer="+key+": "+c.trailers[key])
+	}
+
+	if c.isAmend {
+		args = append(args, "--amend")
+	}
+
+	cmd := c.gitCmd(
+		context.Background(),
+		args...,
+	)
+
+	if !c.commitDate.IsZero() {
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		date := fmt.Sprintf("@%d %s", c.commitDate.Unix(), c.commitDate.Format("-0700"))
+		cmd.Env = append(cmd.Env, "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+	}
+
+	return cmd
+}
+
+func (c *Command) Commit(val string) (string, error) {
+	if err := c.checkCommit(val); err != nil {
+		return "", err
+	}
+
+	output, err := c.retry(context.Background(), func() ([]byte, error) {
+		return c.output(c.commit(val))
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(output), nil
+}
+
+// CommitResult describes the commit recorded by CommitParsed.
+type CommitResult struct {
+	Hash      string // full object name of the new commit
+	ShortHash string // abbreviated object name, as shown by git log --oneline
+	Branch    string // branch the commit was recorded on, empty on a detached HEAD
+}
+
+// CommitParsed records changes like Commit, then reads the hash and branch of the new commit,
+// sparing callers a second command.
+func (c *Command) CommitParsed(msg string) (CommitResult, error) {
+	if _, err := c.Commit(msg); err != nil {
+		return CommitResult{}, err
+	}
+
+	ctx := context.Background()
+	output, err := c.output(c.gitCmd(
+		ctx,
+		"log",
+		"-1",
+		"--format=%H%n%h",
+	))
+	if err != nil {
+		return CommitResult{}, err
+	}
+	hashes := splitLines(string(output))
+	if len(hashes) != 2 {
+		return CommitResult{}, fmt.Errorf("unexpected git log output: %q", output)
+	}
+
+	output, err = c.output(c.gitCmd(
+		ctx,
+		"rev-parse",
+		"--abbrev-ref",
+		"HEAD",
+	))
+	if err != nil {
+		return CommitResult{}, err
+	}
+
+	result := CommitResult{Hash: hashes[0], ShortHash: hashes[1], Branch: strings.TrimSpace(string(output))}
+	// git names a detached HEAD "HEAD" instead of a branch.
+	if result.Branch == "HEAD" {
+		result.Branch = ""
+	}
+	return result, nil
+}
+
+// CommitFromFile records changes with the message read from the file at path, the same as git commit -F.
+// A relative path is resolved against the working directory.
+// The file must exist and not be empty, unless path is empty while amending,
+// which keeps the previous message like Commit does.
+func (c *Command) CommitFromFile(path string) (string, error) {
+	// git reads the file relative to the working directory, so check the same file.
+	if path != "" {
+		path = c.resolvePath(path)
+	}
+	if err := c.checkMessageFile(path); err != nil {
+		return "", err
+	}
+	if err := c.checkStaged(); err != nil {
+		return "", err
+	}
+	if err := c.checkTrailers(); err != nil {
+		return "", err
+	}
+
+	output, err := c.retry(context.Background(), func() ([]byte, error) {
+		return c.output(c.commitFromFile(path))
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(output), nil
+}
+
+func (c *Command) commitFromFile(path string) *gitCommand {
+	if c.isAmend && path == "" {
+		return c.commitCmd("--no-edit")
+	}
+	return c.commitCmd("-F", path)
+}
+
+// checkMessageFile returns an error unless path is a non-empty file, or empty while amending.
+func (c *Command) checkMessageFile(path string) error {
+	if c.isAmend && path == "" {
+		return nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read commit message file: %w", err)
+	}
+	if strings.TrimSpace(string(content)) == "" {
+		return fmt.Errorf("%w: %s", errorsEmptyMessageFile, path)
+	}
+	return c.checkSubject(string(content))
+}
+
+// checkStaged returns ErrNothingToCommit when skipIfEmpty is set, HEAD is not being amended
+// and nothing is staged.
+func (c *Command) checkStaged() error {
+	if !c.skipIfEmpty || c.isAmend {
+		return nil
+	}
+	staged, err := c.HasStagedChanges()
+	if err != nil {
+		return err
+	}
+	if !staged {
+		return ErrNothingToCommit
+	}
+	return nil
+}
+
+// checkCommit runs the checks Commit makes on msg and the staged changes before running git.
+func (c *Command) checkCommit(msg string) error {
+	if err := c.checkSubject(c.normalize(msg)); err != nil {
+		return err
+	}
+	if err := c.checkStaged(); err != nil {
+		return err
+	}
+	return c.checkTrailers()
+}
+
+// checkTrailers returns an error when co-authors or trailers are configured
+// and git is too old for git commit --trailer.
+func (c *Command) checkTrailers() error {
+	if len(c.coAuthors) == 0 && len(c.trailers) == 0 {
+		return nil
+	}
+	return c.requireGitVersion(context.Background(), commitTrailerVersion)
+}
+
+// checkSubject returns an error naming the length of the first line of msg
+// when it is longer than maxSubjectLength characters.
+func (c *Command) checkSubject(msg string) error {
+	if c.maxSubjectLength <= 0 {
+		return nil
+	}
+
+	subject, _, _ := strings.Cut(msg, "\n")
+	subject = strings.TrimSuffix(subject, "\r")
+	if n := utf8.RuneCountInString(subject); n > c.maxSubjectLength {
+		return fmt.Errorf("%w: %d characters, maximum is %d", errorsSubjectTooLong, n, c.maxSubjectLength)
+	}
+	return nil
+}
+
+// CommitWithBody records changes with separate subject and body messages,
+// so git formats them as a subject line followed by a blank line and the body.
+// An empty body commits the subject only.
+func (c *Command) CommitWithBody(subject, body string) (string, error) {
+	if err := c.checkCommit(subject); err != nil {
+		return "", err
+	}
+
+	output, err := c.retry(context.Background(), func() ([]byte, error) {
+		return c.output(c.commitWithBody(subject, body))
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(output), nil
+}
+
+func (c *Command) commitWithBody(subject, body string) *gitCommand {
+	subject, body = c.normalize(subject), c.normalize(body)
+	messageArgs := []string{"--message=" + subject}
+	if strings.TrimSpace(body) != "" {
+		messageArgs = append(messageArgs, "--message="+body)
+	}
+	return c.commitCmd(messageArgs...)
+}
+
+// LastCommitMessage returns the full message of the HEAD commit, without trailing newlines,
+// so it can be shown or regenerated before amending.
+func (c *Command) LastCommitMessage() (string, error) {
+	ctx := context.Background()
+	hasCommits, err := c.hasCommits(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !hasCommits {
+		return "", ErrNoCommitsYet
+	}
+
+	output, err := c.output(c.gitCmd(
+		ctx,
+		"log",
+		"-1",
+		"--pretty=%B",
+	))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(output), "\n"), nil
+}
+
+// IsHeadPushed reports whether HEAD is reachable from any remote-tracking branch,
+// in which case amending it rewrites published history. Callers can warn before amending.
+// Remote-tracking branches are only as recent as the last fetch.
+func (c *Command) IsHeadPushed() (bool, error) {
+	ctx := context.Background()
+	hasCommits, err := c.hasCommits(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !hasCommits {
+		return false, ErrNoCommitsYet
+	}
+
+	output, err := c.output(c.gitCmd(
+		ctx,
+		"branch",
+		"--remotes",
+		"--contains",
+		"HEAD",
+	))
+	if err != nil {
+		return false, err
+	}
+
+	return len(splitLines(string(output))) > 0, nil
+}
+
+// CommitDryRun returns the git commit command line that Commit would run for val,
+// quoted for a POSIX shell, without running it. It fails like Commit would before running git,
+// such as when the subject is too long or WithSkipIfEmpty finds nothing staged.
+func (c *Command) CommitDryRun(val string) (string, error) {
+	if err := c.checkCommit(val); err != nil {
+		return "", err
+	}
+	return c.CommitCommandString(val), nil
+}
+
+// CommitCommandString returns the git commit command line that Commit would run for msg,
+// quoted for a POSIX shell, for logging and bug reports.
+func (c *Command) CommitCommandString(msg string) string {
+	return quoteCommand(c.commit(msg).Args)
+}
+
+// quoteCommand joins args into a command line, quoting each argument for a POSIX shell when needed.
+func quoteCommand(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quoteArg(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// quoteArg single-quotes arg unless it only contains characters that are safe in a shell.
+func quoteArg(arg string) string {
+	if arg != "" && strings.Trim(arg, safeShellChars) == "" {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+const safeShellChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789@%_+=:,./-^"
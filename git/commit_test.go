@@ -0,0 +1,583 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommitFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{
+			name: "defaults",
+			want: []string{"git", "commit", "--no-verify", "--signoff", "--message=msg"},
+		},
+		{
+			name: "run hooks",
+			opts: []Option{WithNoVerify(false)},
+			want: []string{"git", "commit", "--signoff", "--message=msg"},
+		},
+		{
+			name: "no signoff",
+			opts: []Option{WithSignoff(false)},
+			want: []string{"git", "commit", "--no-verify", "--message=msg"},
+		},
+		{
+			name: "sign",
+			opts: []Option{WithSignCommit(true)},
+			want: []string{"git", "commit", "--no-verify", "--signoff", "-S", "--message=msg"},
+		},
+		{
+			name: "sign with key",
+			opts: []Option{WithSignCommit(true), WithSigningKey("ABCD1234")},
+			want: []string{"git", "commit", "--no-verify", "--signoff", "-SABCD1234", "--message=msg"},
+		},
+		{
+			name: "neither",
+			opts: []Option{WithNoVerify(false), WithSignoff(false)},
+			want: []string{"git", "commit", "--message=msg"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := mustNew(t, tt.opts...).commit("msg")
+			if !reflect.DeepEqual(cmd.Args, tt.want) {
+				t.Errorf("commit() args = %v, want %v", cmd.Args, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommitSigningFailure(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "a.txt", "b\n")
+	runGit(t, "add", "a.txt")
+	runGit(t, "config", "gpg.program", "false")
+
+	_, err := mustNew(t, WithSignCommit(true)).Commit("sign me")
+	if err == nil {
+		t.Fatal("Commit() error = nil, want signing error")
+	}
+	if !strings.Contains(err.Error(), "gpg") {
+		t.Errorf("Commit() error = %v, want git stderr about gpg", err)
+	}
+}
+
+func TestCommitAuthorAndDate(t *testing.T) {
+	date := time.Date(2020, 1, 2, 3, 4, 5, 0, time.FixedZone("", 2*60*60))
+	cmd := mustNew(t,
+		WithAuthor("Bot", "bot@example.com"),
+		WithCommitDate(date),
+	).commit("msg")
+
+	if !containsArg(cmd.Args, "--author=Bot <bot@example.com>") {
+		t.Errorf("commit() args = %v, want --author=Bot <bot@example.com>", cmd.Args)
+	}
+	for _, want := range []string{
+		"GIT_AUTHOR_DATE=@1577927045 +0200",
+		"GIT_COMMITTER_DATE=@1577927045 +0200",
+	} {
+		if !containsArg(cmd.Env, want) {
+			t.Errorf("commit() env missing %s", want)
+		}
+	}
+
+	if _, err := New(WithAuthor("Bot", "bot.example.com")); !errors.Is(err, errorsInvalidAuthorEmail) {
+		t.Errorf("New() error = %v, want %v", err, errorsInvalidAuthorEmail)
+	}
+}
+
+func TestCommitAuthorStored(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "a.txt", "b\n")
+	runGit(t, "add", "a.txt")
+
+	_, err := mustNew(t,
+		WithAuthor("Bot", "bot@example.com"),
+		WithCommitDate(time.Unix(1577927045, 0).UTC()),
+	).Commit("backfill")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := runGit(t, "log", "-1", "--format=%an <%ae> %at %ct"), "Bot <bot@example.com> 1577927045 1577927045"; got != want {
+		t.Errorf("last commit = %q, want %q", got, want)
+	}
+}
+
+// containsArg reports whether args contains want.
+func containsArg(args []string, want string) bool {
+	for _, arg := range args {
+		if arg == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCommitDryRun(t *testing.T) {
+	got, err := mustNew(t, WithEnableAmend(true)).CommitDryRun("fix: don't panic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `git commit --no-verify --signoff '--message=fix: don'\''t panic' --amend`
+	if got != want {
+		t.Errorf("CommitDryRun() = %s, want %s", got, want)
+	}
+}
+
+func TestCommitDryRunChecks(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+
+	if _, err := mustNew(t, WithMaxSubjectLength(5)).CommitDryRun("feat: too long"); !errors.Is(err, errorsSubjectTooLong) {
+		t.Errorf("CommitDryRun() error = %v, want %v", err, errorsSubjectTooLong)
+	}
+	if _, err := mustNew(t, WithSkipIfEmpty(true)).CommitDryRun("feat: x"); !errors.Is(err, ErrNothingToCommit) {
+		t.Errorf("CommitDryRun() error = %v, want %v", err, ErrNothingToCommit)
+	}
+	if head := runGit(t, "rev-list", "--count", "HEAD"); head != "1" {
+		t.Errorf("commit count = %s, want the dry run to commit nothing", head)
+	}
+}
+
+func TestCommitAmendNoEdit(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "keep this message")
+	writeFile(t, "b.txt", "b\n")
+	runGit(t, "add", "b.txt")
+
+	g := mustNew(t, WithEnableAmend(true), WithSignoff(false))
+	args := g.commit("").Args
+	if !containsArg(args, "--no-edit") {
+		t.Errorf("commit() args = %v, want --no-edit", args)
+	}
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--message") {
+			t.Errorf("commit() args = %v, want no --message", args)
+		}
+	}
+
+	if _, err := g.Commit(""); err != nil {
+		t.Fatal(err)
+	}
+	if got := runGit(t, "log", "--format=%s"); got != "keep this message" {
+		t.Errorf("log = %q, want a single commit with the original message", got)
+	}
+	if got := runGit(t, "show", "--name-only", "--format="); got != "a.txt\nb.txt" {
+		t.Errorf("amended commit files = %q, want a.txt and b.txt", got)
+	}
+}
+
+func TestCommitParsed(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "b.txt", "b\n")
+	runGit(t, "add", "b.txt")
+
+	got, err := mustNew(t).CommitParsed("add b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CommitResult{
+		Hash:      runGit(t, "rev-parse", "HEAD"),
+		ShortHash: runGit(t, "rev-parse", "--short", "HEAD"),
+		Branch:    "main",
+	}
+	if got != want {
+		t.Errorf("CommitParsed() = %+v, want %+v", got, want)
+	}
+
+	runGit(t, "checkout", "-q", "--detach")
+	writeFile(t, "c.txt", "c\n")
+	runGit(t, "add", "c.txt")
+	got, err = mustNew(t).CommitParsed("add c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Hash != runGit(t, "rev-parse", "HEAD") || got.Branch != "" {
+		t.Errorf("CommitParsed() on a detached HEAD = %+v, want the new hash and no branch", got)
+	}
+}
+
+func TestWithNormalizeMessage(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "b.txt", "b\n")
+	runGit(t, "add", "b.txt")
+
+	msg := "feat: add b  \r\n\r\nExplain why.\t\r\n"
+	if args := mustNew(t).commit(msg).Args; !containsArg(args, "--message="+msg) {
+		t.Errorf("commit() args = %q, want the message unchanged by default", args)
+	}
+
+	g := mustNew(t, WithNormalizeMessage(true), WithSignoff(false))
+	if args := g.commit(msg).Args; !containsArg(args, "--message=feat: add b\n\nExplain why.\n") {
+		t.Errorf("commit() args = %q, want the normalized message", args)
+	}
+	if _, err := g.Commit(msg); err != nil {
+		t.Fatal(err)
+	}
+	if got := runGit(t, "log", "-1", "--format=%B"); got != "feat: add b\n\nExplain why." {
+		t.Errorf("stored message = %q, want it without CRLF or trailing spaces", got)
+	}
+}
+
+func TestWithMaxSubjectLength(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "b.txt", "b\n")
+	runGit(t, "add", "b.txt")
+
+	subject := "feat: " + strings.Repeat("x", 67)
+	msg := subject + "\n\n" + strings.Repeat("body ", 30)
+
+	_, err := mustNew(t, WithMaxSubjectLength(72)).Commit(msg)
+	if !errors.Is(err, errorsSubjectTooLong) || !strings.Contains(err.Error(), "73 characters") {
+		t.Errorf("Commit() error = %v, want %v naming 73 characters", err, errorsSubjectTooLong)
+	}
+	if got := runGit(t, "log", "--format=%s"); got != "first" {
+		t.Errorf("log = %q, want no commit recorded", got)
+	}
+
+	if _, err := mustNew(t, WithMaxSubjectLength(80)).Commit(msg); err != nil {
+		t.Fatal(err)
+	}
+	if got := runGit(t, "log", "-1", "--format=%s"); got != subject {
+		t.Errorf("subject = %q, want %q", got, subject)
+	}
+}
+
+func TestWithMessageCleanup(t *testing.T) {
+	msg := "feat: add file\n\n# not part of the message\nBody."
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{mode: "strip", want: "feat: add file\n\nBody."},
+		{mode: "verbatim", want: msg},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			setupRepo(t)
+			commitFile(t, "a.txt", "a\n", "first")
+			writeFile(t, "b.txt", "b\n")
+			runGit(t, "add", "b.txt")
+
+			g := mustNew(t, WithMessageCleanup(tt.mode), WithSignoff(false))
+			if args := g.commit(msg).Args; !containsArg(args, "--cleanup="+tt.mode) {
+				t.Errorf("commit() args = %v, want --cleanup=%s", args, tt.mode)
+			}
+			if _, err := g.Commit(msg); err != nil {
+				t.Fatal(err)
+			}
+			if got := runGit(t, "log", "-1", "--format=%B"); got != tt.want {
+				t.Errorf("stored message = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	for _, arg := range mustNew(t).commit(msg).Args {
+		if strings.HasPrefix(arg, "--cleanup") {
+			t.Errorf("commit() args contain %s, want git's default", arg)
+		}
+	}
+	if _, err := New(WithMessageCleanup("comments")); !errors.Is(err, errorsInvalidCleanupMode) {
+		t.Errorf("New() error = %v, want %v", err, errorsInvalidCleanupMode)
+	}
+}
+
+func TestLastCommitMessage(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "a.txt", "b\n")
+	runGit(t, "add", "a.txt")
+	runGit(t, "commit", "-q", "-m", "feat: update a", "-m", "Explain why.\n\nRefs: #1")
+
+	got, err := mustNew(t).LastCommitMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "feat: update a\n\nExplain why.\n\nRefs: #1"; got != want {
+		t.Errorf("LastCommitMessage() = %q, want %q", got, want)
+	}
+}
+
+func TestWithCoAuthors(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "a.txt", "b\n")
+	runGit(t, "add", "a.txt")
+
+	coAuthors := []string{"Alice <alice@example.com>", "Bob Smith <bob@example.com>"}
+	if _, err := mustNew(t, WithSignoff(false), WithCoAuthors(coAuthors)).Commit("feat: pair on a"); err != nil {
+		t.Fatal(err)
+	}
+
+	message := runGit(t, "log", "-1", "--format=%B")
+	for _, coAuthor := range coAuthors {
+		if got := strings.Count(message, "Co-authored-by: "+coAuthor); got != 1 {
+			t.Errorf("trailer for %s appears %d times, want 1:\n%s", coAuthor, got, message)
+		}
+	}
+	if !strings.HasPrefix(message, "feat: pair on a\n\n") {
+		t.Errorf("message = %q, want the trailers after a blank line", message)
+	}
+
+	for _, coAuthor := range []string{"Alice", "alice@example.com", "<alice@example.com>", "Alice <alice>", "Alice <a@b.c> extra"} {
+		if _, err := New(WithCoAuthors([]string{coAuthor})); !errors.Is(err, errorsInvalidCoAuthor) {
+			t.Errorf("New(%q) error = %v, want %v", coAuthor, err, errorsInvalidCoAuthor)
+		}
+	}
+}
+
+func TestWithExtraTrailers(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "a.txt", "b\n")
+	runGit(t, "add", "a.txt")
+
+	trailers := map[string]string{
+		"Reviewed-by": "Carol <carol@example.com>",
+		"Refs":        "#42",
+		"Acked-by":    "Dave <dave@example.com>",
+	}
+	if _, err := mustNew(t, WithSignoff(false), WithExtraTrailers(trailers)).Commit("fix: update a"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "fix: update a\n\nAcked-by: Dave <dave@example.com>\nRefs: #42\nReviewed-by: Carol <carol@example.com>"
+	if got := runGit(t, "log", "-1", "--format=%B"); got != want {
+		t.Errorf("message = %q, want %q", got, want)
+	}
+
+	for _, invalid := range []map[string]string{
+		{"Reviewed by": "Carol"},
+		{"Refs:": "#42"},
+		{"Refs": ""},
+		{"Refs": "#1\nSigned-off-by: Mallory"},
+	} {
+		if _, err := New(WithExtraTrailers(invalid)); !errors.Is(err, errorsInvalidTrailer) {
+			t.Errorf("New(%q) error = %v, want %v", invalid, err, errorsInvalidTrailer)
+		}
+	}
+}
+
+func TestCommitFromFile(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "a.txt", "b\n")
+	runGit(t, "add", "a.txt")
+
+	messageFile := filepath.Join(t.TempDir(), "message.txt")
+	writeFile(t, messageFile, "ci: update a\n\nPrepared by the pipeline.\n")
+
+	g := mustNew(t, WithSignoff(false))
+	args := g.commitFromFile(messageFile).Args
+	if !reflect.DeepEqual(args[len(args)-2:], []string{"-F", messageFile}) {
+		t.Errorf("commitFromFile() args = %v, want -F %s", args, messageFile)
+	}
+
+	if _, err := g.CommitFromFile(messageFile); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := runGit(t, "log", "-1", "--format=%B"), "ci: update a\n\nPrepared by the pipeline."; got != want {
+		t.Errorf("commit message = %q, want %q", got, want)
+	}
+
+	emptyFile := filepath.Join(t.TempDir(), "empty.txt")
+	writeFile(t, emptyFile, "\n")
+	if _, err := g.CommitFromFile(emptyFile); !errors.Is(err, errorsEmptyMessageFile) {
+		t.Errorf("CommitFromFile(empty) error = %v, want %v", err, errorsEmptyMessageFile)
+	}
+	if _, err := g.CommitFromFile(filepath.Join(t.TempDir(), "missing.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("CommitFromFile(missing) error = %v, want %v", err, os.ErrNotExist)
+	}
+
+	if args := mustNew(t, WithEnableAmend(true)).commitFromFile("").Args; !containsArg(args, "--no-edit") {
+		t.Errorf("commitFromFile() amend args = %v, want --no-edit", args)
+	}
+}
+
+func TestCommitFromFileWorkingDir(t *testing.T) {
+	dir := setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "a.txt", "b\n")
+	runGit(t, "add", "a.txt")
+	writeFile(t, "msg.txt", "ci: update a\n")
+
+	// A message file with the same name in the process directory must not be read instead.
+	outside := t.TempDir()
+	if err := os.Chdir(outside); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, "msg.txt", "ci: this subject is far too long for the configured limit\n")
+
+	g := mustNew(t, WithWorkingDir(dir), WithMaxSubjectLength(20))
+	if _, err := g.CommitFromFile("msg.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if got := runGit(t, "-C", dir, "log", "-1", "--format=%s"); got != "ci: update a" {
+		t.Errorf("commit subject = %q, want the message file in the working directory", got)
+	}
+
+	if err := os.Remove(filepath.Join(outside, "msg.txt")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "a.txt"), "c\n")
+	runGit(t, "-C", dir, "add", "a.txt")
+	if _, err := g.CommitFromFile("msg.txt"); err != nil {
+		t.Errorf("CommitFromFile() error = %v, want the file found in the working directory", err)
+	}
+}
+
+func TestIsHeadPushed(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	runGit(t, "update-ref", "refs/remotes/origin/main", "HEAD")
+
+	g := mustNew(t)
+	pushed, err := g.IsHeadPushed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pushed {
+		t.Error("IsHeadPushed() = false, want true for a commit on origin/main")
+	}
+
+	commitFile(t, "b.txt", "b\n", "local only")
+	pushed, err = g.IsHeadPushed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pushed {
+		t.Error("IsHeadPushed() = true, want false for a local-only commit")
+	}
+}
+
+func TestCommitCommandString(t *testing.T) {
+	got := mustNew(t, WithSignoff(false), WithAuthor("Jane Doe", "jane@example.com")).CommitCommandString("feat: add x")
+	want := `git commit --no-verify '--author=Jane Doe <jane@example.com>' '--message=feat: add x'`
+	if got != want {
+		t.Errorf("CommitCommandString() = %s, want %s", got, want)
+	}
+}
+
+func TestQuoteArg(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{arg: "--amend", want: "--amend"},
+		{arg: "HEAD^", want: "HEAD^"},
+		{arg: "", want: "''"},
+		{arg: "two words", want: "'two words'"},
+		{arg: "it's", want: `'it'\''s'`},
+		{arg: "$(id)", want: "'$(id)'"},
+	}
+	for _, tt := range tests {
+		if got := quoteArg(tt.arg); got != tt.want {
+			t.Errorf("quoteArg(%q) = %s, want %s", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestCommitWithBody(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+
+	tests := []struct {
+		name    string
+		subject string
+		body    string
+		want    string
+	}{
+		{
+			name:    "subject and body",
+			subject: "feat: add b",
+			body:    "- explain why\n- and how",
+			want:    "feat: add b\n\n- explain why\n- and how",
+		},
+		{
+			name:    "subject only",
+			subject: "chore: add c",
+			want:    "chore: add c",
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := mustNew(t, WithSignoff(false))
+
+			var messages int
+			for _, arg := range g.commitWithBody(tt.subject, tt.body).Args {
+				if strings.HasPrefix(arg, "--message=") {
+					messages++
+				}
+			}
+			if want := strings.Count(tt.want, "\n\n") + 1; messages != want {
+				t.Errorf("commitWithBody() has %d message args, want %d", messages, want)
+			}
+
+			writeFile(t, "a.txt", strconv.Itoa(i))
+			runGit(t, "add", "a.txt")
+			if _, err := g.CommitWithBody(tt.subject, tt.body); err != nil {
+				t.Fatal(err)
+			}
+			if got := runGit(t, "log", "-1", "--format=%B"); got != tt.want {
+				t.Errorf("commit message = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommitEmpty(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "init")
+	count := func() string { return strings.TrimSpace(runGit(t, "rev-list", "--count", "HEAD")) }
+
+	if _, err := mustNew(t).Commit("chore: nothing"); err == nil {
+		t.Error("Commit() with nothing staged should fail")
+	}
+
+	if _, err := mustNew(t, WithAllowEmpty(true)).Commit("chore: empty"); err != nil {
+		t.Fatal(err)
+	}
+	if got := count(); got != "2" {
+		t.Errorf("commits after WithAllowEmpty = %s, want 2", got)
+	}
+
+	g := mustNew(t, WithSkipIfEmpty(true))
+	if _, err := g.Commit("chore: skipped"); !errors.Is(err, ErrNothingToCommit) {
+		t.Errorf("Commit() error = %v, want %v", err, ErrNothingToCommit)
+	}
+	if _, err := g.CommitWithBody("chore: skipped", "body"); !errors.Is(err, ErrNothingToCommit) {
+		t.Errorf("CommitWithBody() error = %v, want %v", err, ErrNothingToCommit)
+	}
+	if got := count(); got != "2" {
+		t.Errorf("commits after WithSkipIfEmpty = %s, want 2", got)
+	}
+
+	writeFile(t, "a.txt", "b\n")
+	runGit(t, "add", "a.txt")
+	if _, err := g.Commit("fix: change a"); err != nil {
+		t.Fatal(err)
+	}
+	if got := count(); got != "3" {
+		t.Errorf("commits after staging = %s, want 3", got)
+	}
+
+	if _, err := New(WithAllowEmpty(true), WithSkipIfEmpty(true)); !errors.Is(err, errorsAllowAndSkipEmpty) {
+		t.Errorf("New() error = %v, want %v", err, errorsAllowAndSkipEmpty)
+	}
+}
@@ -0,0 +1,81 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxConventionalSubjectLength is the maximum length of a conventional commit subject line.
+const MaxConventionalSubjectLength = 72
+
+// ConventionalTypes lists the commit types accepted by ValidateConventional.
+var ConventionalTypes = []string{
+	"build", "chore", "ci",
+	"docs", "feat", "fix",
+	"perf", "refactor", "revert",
+	"style", "test",
+}
+
+var (
+	errorsMissingCommitType = errors.New("missing conventional commit type")
+	errorsInvalidCommitType = errors.New("invalid conventional commit type")
+	errorsSubjectTooLong    = errors.New("commit subject is too long")
+)
+
+// conventionalHeader matches "type(scope)!: description", with optional scope and breaking marker.
+var conventionalHeader = regexp.MustCompile(`^(\w+)(?:\(([^()]*)\))?(!)?: (\S.*)$`)
+
+// ParsedCommit holds the components of a conventional commit message.
+type ParsedCommit struct {
+	Type        string
+	Scope       string
+	Breaking    bool
+	Description string
+	Body        string
+}
+
+// ParseConventional parses msg as a Conventional Commits message of the form
+// "type(scope): description", followed by an optional body after a blank line.
+func ParseConventional(msg string) (ParsedCommit, error) {
+	msg = strings.TrimSpace(strings.ReplaceAll(msg, "\r\n", "\n"))
+	header, body, _ := strings.Cut(msg, "\n")
+
+	if n := utf8.RuneCountInString(header); n > MaxConventionalSubjectLength {
+		return ParsedCommit{}, fmt.Errorf("%w: %d characters, maximum is %d", errorsSubjectTooLong, n, MaxConventionalSubjectLength)
+	}
+
+	m := conventionalHeader.FindStringSubmatch(header)
+	if m == nil {
+		return ParsedCommit{}, fmt.Errorf("%w: %q does not match type(scope): description", errorsMissingCommitType, header)
+	}
+
+	if !isConventionalType(m[1]) {
+		return ParsedCommit{}, fmt.Errorf("%w: %q, must be one of %s", errorsInvalidCommitType, m[1], strings.Join(ConventionalTypes, ", "))
+	}
+
+	return ParsedCommit{
+		Type:        m[1],
+		Scope:       m[2],
+		Breaking:    m[3] == "!",
+		Description: m[4],
+		Body:        strings.TrimSpace(body),
+	}, nil
+}
+
+// ValidateConventional returns a descriptive error when msg is not a valid Conventional Commits message.
+func ValidateConventional(msg string) error {
+	_, err := ParseConventional(msg)
+	return err
+}
+
+func isConventionalType(val string) bool {
+	for _, t := range ConventionalTypes {
+		if t == val {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package git
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseConventional(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     string
+		want    ParsedCommit
+		wantErr error
+	}{
+		{
+			name: "type only",
+			msg:  "fix: handle empty diff",
+			want: ParsedCommit{Type: "fix", Description: "handle empty diff"},
+		},
+		{
+			name: "scope and body",
+			msg:  "feat(git): add commit range\n\nAllows reviewing a range.\n",
+			want: ParsedCommit{Type: "feat", Scope: "git", Description: "add commit range", Body: "Allows reviewing a range."},
+		},
+		{
+			name: "breaking change",
+			msg:  "refactor(api)!: drop IsDiffTag",
+			want: ParsedCommit{Type: "refactor", Scope: "api", Breaking: true, Description: "drop IsDiffTag"},
+		},
+		{
+			name:    "missing type",
+			msg:     "add commit range",
+			wantErr: errorsMissingCommitType,
+		},
+		{
+			name:    "missing description",
+			msg:     "feat: ",
+			wantErr: errorsMissingCommitType,
+		},
+		{
+			name:    "invalid type",
+			msg:     "feature: add commit range",
+			wantErr: errorsInvalidCommitType,
+		},
+		{
+			name:    "subject too long",
+			msg:     "feat: " + strings.Repeat("a", 67),
+			wantErr: errorsSubjectTooLong,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseConventional(tt.msg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseConventional() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseConventional() = %+v, want %+v", got, tt.want)
+			}
+			if err := ValidateConventional(tt.msg); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateConventional() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IsExcluded reports whether path, relative to the repository root, matches the effective exclude list,
+// including DefaultExcludeFromDiff unless replaced, so callers can show why a file is not in the diff.
+// Patterns match the way git matches the exclude pathspecs, honoring glob semantics for "**".
+func (c *Command) IsExcluded(path string) bool {
+	path = strings.TrimPrefix(filepath.ToSlash(path), "./")
+	for _, f := range c.excludeList {
+		if c.matchExclude(f, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchExclude reports whether path matches the exclude pattern under the configured pathspec magic.
+func (c *Command) matchExclude(pattern, path string) bool {
+	if hasMagicWord(c.excludeMagic, "icase") {
+		pattern, path = strings.ToLower(pattern), strings.ToLower(path)
+	}
+	if hasMagicWord(c.excludeMagic, "literal") {
+		pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "/"), "/")
+		return path == pattern || strings.HasPrefix(path, pattern+"/")
+	}
+	glob := strings.Contains(pattern, "**") || hasMagicWord(c.excludeMagic, "glob")
+	return matchPathspec(pattern, path, glob)
+}
+
+// readExcludeFile returns the exclude patterns listed in the file at path, one per line,
+// skipping blank lines and # comments.
+func readExcludeFile(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read exclude file: %w", err)
+	}
+
+	var patterns []string
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, nil
+}
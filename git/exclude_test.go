@@ -0,0 +1,74 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithExcludeFile(t *testing.T) {
+	dir := setupRepo(t)
+	for _, name := range []string{"main.go", "docs/a.md", "gen/b.pb.go", "c.txt"} {
+		writeFile(t, name, "a\n")
+	}
+	runGit(t, "add", ".")
+	runGit(t, "commit", "-q", "-m", "init")
+	for _, name := range []string{"main.go", "docs/a.md", "gen/b.pb.go", "c.txt"} {
+		writeFile(t, name, "b\n")
+	}
+	writeFile(t, "excludes.txt", "# generated code\n**/*.pb.go\n\n  docs/**  \r\n")
+
+	output, err := diffNames(t, mustNew(t,
+		WithExcludeList([]string{"c.txt"}),
+		WithExcludeFile("excludes.txt"),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Fields(string(output)), []string{"main.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("diffNames() = %v, want %v", got, want)
+	}
+
+	// A relative path is resolved against the working directory, not the process directory.
+	if err := os.Chdir(os.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(WithWorkingDir(dir), WithExcludeFile("excludes.txt")); err != nil {
+		t.Errorf("New() error = %v, want the file found in the working directory", err)
+	}
+
+	if _, err := New(WithExcludeFile(filepath.Join(dir, "missing.txt"))); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("New() error = %v, want a missing file error", err)
+	}
+}
+
+func TestIsExcluded(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		path string
+		want bool
+	}{
+		{name: "default literal", path: "go.sum", want: true},
+		{name: "default literal in subdirectory", path: "sub/go.sum", want: false},
+		{name: "default wildcard", path: "yarn.lock", want: true},
+		{name: "wildcard crosses directories", path: "web/Cargo.lock", want: true},
+		{name: "not excluded", path: "main.go", want: false},
+		{name: "user glob", opts: []Option{WithExcludeList([]string{"vendor/**"})}, path: "vendor/a/b.go", want: true},
+		{name: "glob star stops at slash", opts: []Option{WithExcludeList([]string{"docs/*/**.md"})}, path: "docs/a.md", want: false},
+		{name: "directory", opts: []Option{WithExcludeList([]string{"third_party"})}, path: "./third_party/x.c", want: true},
+		{name: "replaced defaults", opts: []Option{WithReplaceDefaultExcludes(true)}, path: "go.sum", want: false},
+		{name: "literal magic", opts: []Option{WithExcludeList([]string{"*.go"}), WithExcludePathspecMagic("exclude,top,literal")}, path: "main.go", want: false},
+		{name: "icase magic", opts: []Option{WithExcludePathspecMagic("exclude,top,icase")}, path: "GO.SUM", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mustNew(t, tt.opts...).IsExcluded(tt.path); got != tt.want {
+				t.Errorf("IsExcluded(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package git
+
+import (
+	"context"
+	"regexp"
+)
+
+// generatedMarkerSize is how many bytes at the start of a file are searched for a generated-code marker.
+const generatedMarkerSize = 4096
+
+// generatedMarker matches the comment that marks generated Go code, see https://go.dev/s/generatedcode.
+var generatedMarker = regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$`)
+
+// isGenerated reports whether the staged blob of path carries a generated-code marker.
+// Files missing from the index, such as deleted files, are not considered generated.
+func (c *Command) isGenerated(ctx context.Context, path string) bool {
+	output, err := c.output(c.gitCmd(ctx, "show", ":"+path))
+	if err != nil {
+		return false
+	}
+	if len(output) > generatedMarkerSize {
+		output = output[:generatedMarkerSize]
+	}
+	return generatedMarker.Match(output)
+}
+
+// dropGenerated returns files without the generated ones.
+func (c *Command) dropGenerated(ctx context.Context, files []string) []string {
+	var kept []string
+	for _, f := range files {
+		if !c.isGenerated(ctx, f) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
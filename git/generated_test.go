@@ -0,0 +1,58 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithExcludeGenerated(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "main.go", "package main\n", "add main")
+	commitFile(t, "main.pb.go", "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage main\n", "add generated")
+
+	writeFile(t, "main.go", "package main\n\nfunc main() {}\n")
+	writeFile(t, "main.pb.go", "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage main\n\nvar x = 1\n")
+	runGit(t, "add", "-A")
+	writeFile(t, "main.go", "package main\n\nfunc main() { println() }\n")
+	writeFile(t, "main.pb.go", "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage main\n\nvar x = 2\n")
+
+	files, err := mustNew(t).ChangedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"main.go", "main.pb.go"}; !reflect.DeepEqual(files, want) {
+		t.Fatalf("without option: got %v, want %v", files, want)
+	}
+
+	g := mustNew(t, WithExcludeGenerated(true))
+	files, err = g.ChangedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"main.go"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("ChangedFiles() = %v, want %v", files, want)
+	}
+
+	diff, err := g.DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "main.go") || strings.Contains(diff, "main.pb.go") {
+		t.Errorf("DiffFiles() = %q, want only the main.go patch", diff)
+	}
+}
+
+func TestWithExcludeGeneratedNonASCII(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "café.txt", "a\n", "init")
+	commitFile(t, "café.txt", "b\n", "change")
+
+	diff, err := mustNew(t, WithExcludeGenerated(true), WithLastCommit(true)).DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "+b") {
+		t.Errorf("DiffFiles() = %q, want the café.txt patch", diff)
+	}
+}
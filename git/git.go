@@ -1,18 +1,43 @@
 package git
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
-	"path"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
-
-	"github.com/appleboy/com/file"
-	"github.com/carsonfeng/ZCode/util"
+	"time"
 )
 
+// ErrNoStagedChanges is returned by DiffFiles when there are no changes to diff.
+var ErrNoStagedChanges = errors.New("please add your staged changes using git add <files...>")
+
+// timeoutWaitDelay is how long a command killed by WithTimeout may keep its output open.
+const timeoutWaitDelay = time.Second
+
+// ErrNoUpstream is returned by WithUpstream diffs when the current branch does not track an upstream branch.
+var ErrNoUpstream = errors.New("no upstream branch is configured for the current branch")
+
+// ErrNotMergeCommit is returned by WithMergeCommit diffs when the commit has a single parent.
+var ErrNotMergeCommit = errors.New("not a merge commit")
+
+// ErrGitNotFound is returned by New and CheckGit when the git executable cannot be found.
+var ErrGitNotFound = errors.New("git executable not found, please install git or set its path with WithGitBinary")
+
+// ErrNoCommitsYet is returned when an operation needs HEAD in a repository without commits.
+var ErrNoCommitsYet = errors.New("the repository has no commits yet")
+
+// emptyTree is the hash of the tree with no files in SHA-1 repositories, which git knows without it being stored.
+// EmptyTreeSHA falls back to it when git cannot compute the hash.
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// excludeFromDiff are the files left out of every diff unless WithReplaceDefaultExcludes or WithNoDefaultExcludes is set:
+// lock files, which are long, generated and rarely worth reviewing.
 var excludeFromDiff = []string{
 	"package-lock.json",
 	"pnpm-lock.yaml",
@@ -21,244 +46,873 @@ var excludeFromDiff = []string{
 	"go.sum",
 }
 
+// DefaultExcludeFromDiff returns a copy of the files excluded from every diff by default,
+// so callers can build their own exclude list from them with WithReplaceDefaultExcludes.
+func DefaultExcludeFromDiff() []string {
+	return append([]string{}, excludeFromDiff...)
+}
+
+// Command runs git with the configuration built by New from its options.
+// The configuration never changes after New returns, and slices passed to options are copied,
+// so a Command is safe for concurrent use by multiple goroutines.
 type Command struct {
 	// Generate diffs with <n> lines of context instead of the usual three
 	diffUnified   int
+	includeList   []string
 	excludeList   []string
 	isAmend       bool
+	lastCommit    bool   // review the changes of the HEAD commit, without amending it
 	diffTagPrefix string // review latest two tags commit changes diff tags is grep by this string. If empty, ignore this option.
 	diffList      []string
 	commitId      string // review commit changes diff by commit id. If empty, ignore this option.
+	mergeCommit   string // review the changes a merge commit brought in. If empty, ignore this option.
+	mergeParent   int    // parent of mergeCommit to compare against, starting at 1
+	commitFrom    string // review changes between commitFrom and commitTo. If empty, ignore this option.
+	commitTo      string // end of the commit range, defaults to HEAD when empty.
+	baseBranch    string // review changes on headBranch since it diverged from baseBranch. If empty, ignore this option.
+	headBranch    string
+	upstream      bool   // review changes on HEAD since it diverged from its upstream branch
+	tagToHead     string // review changes from this tag to HEAD. If empty, ignore this option.
+	stashRef      string // review the changes saved in this stash entry. If empty, ignore this option.
+	excludeMagic  string // pathspec magic words of each exclude, such as "exclude,top"
+	tagSort       string // git tag --sort key used to find the latest tags, sorted descending
+	tagPattern    string // only compare tags matching this glob, or regular expression if tagRegexp is set
+	tagRegexp     *regexp.Regexp
+	// detect renames with the given similarity threshold in percent. If zero, renames are not detected.
+	renameThreshold  int
+	detectCopies     bool
+	rangeRenames     bool // detect renames in diffs between revisions, such as tag and commit ranges
+	firstParent      bool // only follow the first parent of merges when listing the commits of a range
+	wordDiff         bool
+	diffAlgorithm    string
+	whitespaceMode   string
+	gitBinary        string   // path of the git executable
+	env              []string // extra environment variables added to the inherited environment
+	workingDir       string   // directory git runs in. If empty, use the current working directory.
+	noVerify         bool     // bypass the pre-commit and commit-msg hooks when committing
+	signoff          bool     // add a Signed-off-by trailer when committing
+	allowEmpty       bool     // record commits even when nothing is staged
+	skipIfEmpty      bool     // return ErrNothingToCommit instead of committing when nothing is staged
+	normalizeMessage bool     // strip trailing whitespace and CRLF line endings from commit messages
+	maxSubjectLength int      // reject commit messages with a longer first line. If zero, ignore this option.
+	cleanupMode      string   // how git commit --cleanup cleans up messages. If empty, use git's default.
+	signCommit       bool     // GPG/SSH sign commits
+	signingKey       string   // key used to sign commits. If empty, use git's configured default key.
+	authorName       string   // override the commit author. If empty, use git's configured identity.
+	authorEmail      string
+	coAuthors        []string    // added as Co-authored-by trailers, each formatted as "Name <email>"
+	commitDate       time.Time   // override the author and committer dates. If zero, use the current time.
+	forceHook        bool        // overwrite an existing hook previously installed by zcode
+	diffBackend      diffBackend // computes ChangedFiles and DiffFiles
+	maxDiffBytes     int         // truncate DiffFiles output to this many bytes. If zero, ignore this option.
+	maxPatchBytes    int         // replace larger per-file patches with a placeholder. If zero, ignore this option.
+	excludeGenerated bool        // drop files whose staged content is marked as generated code
+	omitBinary       bool        // drop binary files from DiffFiles output
+	includeUntracked bool        // fall back to untracked files when the working tree has no changes
+	functionContext  bool        // show the whole enclosing function as context
+	textConv         bool        // convert files with their configured textconv diff driver
+	color            bool        // keep ANSI colors in DiffFiles output instead of disabling them
+	submoduleMode    string      // how submodule changes are shown: log, short or diff
+	retryAttempts    int         // run commits and diffs up to this many times on transient failures
+	retryDelay       time.Duration
+	diffFilter       string // only show changes of these types, as in git diff --diff-filter
+	nameStatus       bool   // list changed files with git diff --name-status instead of --name-only
+
+	// timeout kills each git invocation that runs longer. If zero, ignore this option.
+	timeout time.Duration
+	// extraDiffArgs are passed to git diff after the built-in flags.
+	extraDiffArgs []string
+	// trailers are added to commits as "Key: Value" trailers, sorted by key.
+	trailers map[string]string
+	// extensions restrict the diff to files with these extensions, each with a leading dot.
+	extensions []string
+}
+
+// pathspecs returns the include pathspecs followed by the exclude pathspecs,
+// so excludes only apply within the included paths.
+func (c *Command) pathspecs() []string {
+	var specs []string
+	for _, f := range c.includeList {
+		specs = append(specs, ":(top)"+f)
+	}
+	for _, f := range c.diffList {
+		specs = append(specs, ":(top,literal)"+f)
+	}
+	for _, ext := range c.extensions {
+		specs = append(specs, ":(top,glob)**/*"+ext)
+	}
+	return append(specs, c.excludeFiles()...)
 }
 
+// excludeFiles converts the exclude list into git pathspecs.
+// Entries containing "**" use glob magic, so they behave like .gitignore patterns:
+// "*" stops at a slash and "**" matches across directories, e.g. "vendor/**" or "**/*.generated.go".
+// Other entries are literal excludes matched against the path from the repository root.
 func (c *Command) excludeFiles() []string {
 	var excludedFiles []string
 	for _, f := range c.excludeList {
-		excludedFiles = append(excludedFiles, ":(exclude,top)"+f)
+		magic := c.excludeMagic
+		if strings.Contains(f, "**") && !hasMagicWord(magic, "glob") && !hasMagicWord(magic, "literal") {
+			magic += ",glob"
+		}
+		excludedFiles = append(excludedFiles, ":("+magic+")"+f)
 	}
 	return excludedFiles
 }
 
-// IsDiffTag judge whether to compare the differences between the latest two tags
-func (c *Command) IsDiffTag() (is bool, tagNew, tagOld string) {
-	if c.diffTagPrefix != "" {
-		is = true
-		tagCmd := c.latestTwoTags(c.diffTagPrefix)
-		output, err := tagCmd.Output()
+// diffRange returns the git subcommand and the revision arguments that select which changes are compared.
+// An explicit commit range wins over a branch comparison, then the upstream branch, stashRef, tagToHead, the latest two tags, and finally isAmend or lastCommit.
+// With no range configured, the working tree is compared against the index,
+// or the index against the empty tree when there are no commits yet.
+// Comparing the latest two tags fails with ErrNoMatchingTags when fewer than two match.
+func (c *Command) diffRange(ctx context.Context) (subcommand, revs []string, err error) {
+	subcommand = []string{"diff"}
+
+	switch {
+	case c.commitFrom != "":
+		if c.commitTo == "" {
+			revs = []string{c.commitFrom + "..HEAD"}
+		} else {
+			revs = []string{c.commitFrom, c.commitTo}
+		}
+	case c.baseBranch != "" && c.headBranch != "":
+		for _, ref := range []string{c.baseBranch, c.headBranch} {
+			if err := c.verifyRef(ctx, ref); err != nil {
+				return nil, nil, err
+			}
+		}
+		revs = []string{c.baseBranch + "..." + c.headBranch}
+	case c.upstream:
+		if err := c.verifyUpstream(ctx); err != nil {
+			return nil, nil, err
+		}
+		revs = []string{"@{upstream}...HEAD"}
+	case c.stashRef != "":
+		if err := c.verifyRef(ctx, c.stashRef); err != nil {
+			return nil, nil, err
+		}
+		// diffFiles passes --unified, which makes git stash show print the patch like --patch.
+		return []string{"stash", "show"}, []string{c.stashRef}, nil
+	case c.tagToHead != "":
+		if err := c.verifyRef(ctx, "refs/tags/"+c.tagToHead); err != nil {
+			return nil, nil, err
+		}
+		revs = []string{"refs/tags/" + c.tagToHead, "HEAD"}
+	case c.diffTagPrefix != "" || c.tagPattern != "":
+		tags, err := c.latestTagPair(ctx)
 		if err != nil {
-			return false, "", ""
+			return nil, nil, err
+		}
+		revs = []string{tags.Older, tags.Newer}
+	case c.mergeCommit != "":
+		if err := c.verifyRef(ctx, c.mergeCommit); err != nil {
+			return nil, nil, err
 		}
-		tags := strings.Split(string(output), " ")
-		if len(tags) == 2 {
-			tagNew, tagOld = tags[0], tags[1]
+		if !c.hasParent(ctx, c.mergeCommit+"^2") {
+			return nil, nil, fmt.Errorf("%w: %s", ErrNotMergeCommit, c.mergeCommit)
+		}
+		parent := c.mergeCommit + "^" + strconv.Itoa(c.mergeParent)
+		if err := c.verifyRef(ctx, parent); err != nil {
+			return nil, nil, err
+		}
+		revs = []string{parent, c.mergeCommit}
+	case c.commitId != "":
+		revs = []string{c.parentOrEmptyTree(ctx, c.commitId), c.commitId}
+	case c.isAmend || c.lastCommit:
+		hasCommits, err := c.hasCommits(ctx)
+		if err != nil {
+			return nil, nil, err
 		}
+		if !hasCommits {
+			return nil, nil, ErrNoCommitsYet
+		}
+		revs = []string{c.parentOrEmptyTree(ctx, "HEAD"), "HEAD"}
 	}
-	return
+
+	// Before the first commit, describe what it will contain: the index against the empty tree.
+	if len(revs) == 0 {
+		hasCommits, err := c.hasCommits(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !hasCommits {
+			// An error computing the empty tree surfaces when the diff runs.
+			tree, _ := c.emptyTreeSHA(ctx)
+			return []string{"diff", "--cached"}, []string{tree}, nil
+		}
+	}
+
+	return subcommand, revs, nil
 }
 
-func (c *Command) latestTwoTags(tagGrepHead string) *exec.Cmd {
+// hasCommits reports whether HEAD points to a commit, which is not the case in a freshly initialized repository.
+// Only an unresolvable HEAD reports false; other git failures, such as a timeout, are returned.
+func (c *Command) hasCommits(ctx context.Context) (bool, error) {
+	_, err := c.output(c.gitCmd(
+		ctx,
+		"rev-parse",
+		"--verify",
+		"--quiet",
+		"HEAD",
+	))
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return false, nil
+	default:
+		return false, err
+	}
+}
 
-	cmdStr := fmt.Sprintf("git tag --sort=-creatordate | grep '^%s' | head -n 2 | tr '\\n' ' ' | sed 's/ $//'", tagGrepHead)
+// verifyRef returns an error naming ref when it does not resolve to a git object.
+func (c *Command) verifyRef(ctx context.Context, ref string) error {
+	_, err := c.output(c.gitCmd(
+		ctx,
+		"rev-parse",
+		"--verify",
+		"--quiet",
+		ref,
+	))
+	if err != nil {
+		return fmt.Errorf("ref %s not found: %w", ref, err)
+	}
+	return nil
+}
 
-	return exec.Command("bash", "-c", cmdStr)
+// verifyUpstream returns ErrNoUpstream, with git's explanation, when the current branch has no upstream branch.
+func (c *Command) verifyUpstream(ctx context.Context) error {
+	_, err := c.output(c.gitCmd(
+		ctx,
+		"rev-parse",
+		"--symbolic-full-name",
+		"@{upstream}",
+	))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNoUpstream, err)
+	}
+	return nil
 }
 
-func (c *Command) diffNames() *exec.Cmd {
-	args := []string{
-		"diff",
-		"--name-only",
+// parentOrEmptyTree returns the first parent of rev, or the empty tree when rev is a root commit,
+// so the changes of a root commit are compared against nothing.
+func (c *Command) parentOrEmptyTree(ctx context.Context, rev string) string {
+	if c.hasParent(ctx, rev) {
+		return rev + "^"
+	}
+	// An error computing the empty tree surfaces when the diff runs.
+	tree, _ := c.emptyTreeSHA(ctx)
+	return tree
+}
+
+// EmptyTreeSHA returns the object name of the tree with no files, in the hash format of the repository,
+// which a first commit is compared against since it has no parent.
+// When git cannot compute it, the SHA-1 empty tree is returned along with the error.
+func (c *Command) EmptyTreeSHA() (string, error) {
+	return c.emptyTreeSHA(context.Background())
+}
+
+func (c *Command) emptyTreeSHA(ctx context.Context) (string, error) {
+	output, err := c.output(c.gitCmd(
+		ctx,
+		"hash-object",
+		"-t",
+		"tree",
+		os.DevNull,
+	))
+	if err != nil {
+		return emptyTree, err
 	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// hasParent reports whether the given commit has a parent commit.
+func (c *Command) hasParent(ctx context.Context, rev string) bool {
+	return c.gitCmd(
+		ctx,
+		"rev-parse",
+		"--verify",
+		"--quiet",
+		rev+"^",
+	).run()
+}
+
+// diffCmd returns a diff command over the active range and excludes, using the given flags.
+func (c *Command) diffCmd(ctx context.Context, flags ...string) (*gitCommand, error) {
+	subcommand, revs, err := c.diffRange(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	args := append(subcommand, flags...)
+	if c.diffFilter != "" {
+		args = append(args, "--diff-filter="+c.diffFilter)
+	}
+	args = append(args, c.renameFlags(len(revs) > 0)...)
+	args = append(args, revs...)
+	// git stash show takes no pathspecs, so its output is filtered with selectsPath instead.
+	if c.stashRef == "" {
+		args = append(args, c.pathspecs()...)
+	}
+
+	return c.gitCmd(
+		ctx,
+		args...,
+	), nil
+}
 
-	if c.diffTagPrefix != "" {
-		if is, tagNew, tagOld := c.IsDiffTag(); is && tagNew != "" && tagOld != "" {
-			args = append(args, tagOld, tagNew)
+// renameFlags returns the rename and copy detection flags.
+// Diffs between revisions detect renames with the default threshold when rangeRenames is set,
+// even if WithDetectRenames was not given.
+func (c *Command) renameFlags(isRange bool) []string {
+	threshold := c.renameThreshold
+	if threshold == 0 && isRange && c.rangeRenames {
+		threshold = defaultRenameThreshold
+	}
+	if threshold == 0 {
+		return nil
+	}
+	flags := []string{"-M" + strconv.Itoa(threshold) + "%"}
+	if c.detectCopies {
+		flags = append(flags, "-C")
+	}
+	return flags
+}
+
+// diffNames returns a command listing the changed files as NUL-terminated fields,
+// each preceded by its status letter when nameStatus is set.
+func (c *Command) diffNames(ctx context.Context) (*gitCommand, error) {
+	if c.nameStatus {
+		return c.diffCmd(ctx, "--name-status", "-z")
+	}
+	return c.diffCmd(ctx, "--name-only", "-z")
+}
+
+func (c *Command) diffFiles(ctx context.Context) (*gitCommand, error) {
+	flags, err := c.diffFlags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.diffCmd(ctx, flags...)
+}
+
+// diffFlags returns the git diff flags that shape the patch output of DiffFiles.
+func (c *Command) diffFlags(ctx context.Context) ([]string, error) {
+	var flags []string
+	if flag := whitespaceFlags[c.whitespaceMode]; flag != "" {
+		flags = append(flags, flag)
+	}
+	if !c.extraDiffAlgorithm() {
+		flags = append(flags, "--diff-algorithm="+c.diffAlgorithm)
+	}
+	flags = append(flags, "--unified="+strconv.Itoa(c.diffUnified))
+	if c.wordDiff {
+		flags = append(flags, "--word-diff=porcelain")
+	}
+	if c.functionContext {
+		if err := c.requireGitVersion(ctx, functionContextVersion); err != nil {
+			return nil, err
 		}
-	} else if len(c.diffList) > 0 {
-		args = append(args, c.diffList...)
+		flags = append(flags, "--function-context")
+	}
+	if c.textConv {
+		flags = append(flags, "--textconv")
+	}
+	if c.submoduleMode == "diff" {
+		if err := c.requireGitVersion(ctx, submoduleDiffVersion); err != nil {
+			return nil, err
+		}
+	}
+	flags = append(flags, "--submodule="+c.submoduleMode)
+	// Pass --color explicitly, so color.diff=always in the user's config cannot leak ANSI codes into the output.
+	if c.color {
+		flags = append(flags, "--color=always")
 	} else {
-		if c.commitId != "" {
-			args = append(args, c.commitId)
-		} else if c.isAmend {
-			args = append(args, "HEAD^", "HEAD")
-		} else {
-			//args = append(args, "--staged")
+		flags = append(flags, "--color=never")
+	}
+	return append(flags, c.extraDiffArgs...), nil
+}
+
+// extraDiffAlgorithm reports whether the extra diff arguments choose the diff algorithm,
+// in which case the built-in --diff-algorithm flag is left out.
+func (c *Command) extraDiffAlgorithm() bool {
+	for _, arg := range c.extraDiffArgs {
+		if diffAlgorithmFlags[arg] || strings.HasPrefix(arg, "--diff-algorithm=") {
+			return true
 		}
 	}
+	return false
+}
 
-	excludedFiles := c.excludeFiles()
-	args = append(args, excludedFiles...)
+// gitCommand is a git invocation together with the context that kills it.
+type gitCommand struct {
+	*exec.Cmd
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	timeout time.Duration
+}
 
-	return exec.Command(
-		"git",
-		args...,
-	)
+// gitCmd returns a git command with the given arguments, bound to ctx.
+// It runs the configured git binary with the configured environment and timeout.
+func (c *Command) gitCmd(ctx context.Context, args ...string) *gitCommand {
+	ctx, cancel := context.WithCancelCause(ctx)
+	cmd := &gitCommand{
+		Cmd:     exec.CommandContext(ctx, c.gitBinary, args...),
+		ctx:     ctx,
+		cancel:  cancel,
+		timeout: c.timeout,
+	}
+	if c.timeout > 0 {
+		// Stop waiting for output held open by processes git started, such as hooks, once git is killed.
+		cmd.WaitDelay = timeoutWaitDelay
+	}
+	cmd.Dir = c.workingDir
+	if len(c.env) > 0 {
+		cmd.Env = append(os.Environ(), c.env...)
+	}
+	return cmd
 }
 
-func (c *Command) diffFiles() *exec.Cmd {
-	args := []string{
-		"diff",
-		"--ignore-all-space",
-		"--diff-algorithm=minimal",
-		"--unified=" + strconv.Itoa(c.diffUnified),
+// startTimeout starts counting the WithTimeout deadline, so it must be called when git starts.
+// The returned function releases the command's context once git has exited.
+func (cmd *gitCommand) startTimeout() (stop func()) {
+	if cmd.timeout <= 0 {
+		return func() { cmd.cancel(nil) }
+	}
+	timer := time.AfterFunc(cmd.timeout, func() { cmd.cancel(context.DeadlineExceeded) })
+	return func() {
+		timer.Stop()
+		cmd.cancel(nil)
 	}
-	if c.diffTagPrefix != "" {
-		if is, tagNew, tagOld := c.IsDiffTag(); is && tagNew != "" && tagOld != "" {
-			args = append(args, tagOld, tagNew)
+}
+
+// timedOut reports whether git was killed because its deadline passed.
+func (cmd *gitCommand) timedOut() bool {
+	return errors.Is(context.Cause(cmd.ctx), context.DeadlineExceeded)
+}
+
+// run runs the command, returning whether it succeeded.
+func (cmd *gitCommand) run() bool {
+	defer cmd.startTimeout()()
+	return cmd.Run() == nil
+}
+
+// output runs cmd and returns its standard output.
+// When the command fails, git's standard error is included in the returned error.
+func (c *Command) output(cmd *gitCommand) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	defer cmd.startTimeout()()
+	output, err := cmd.Output()
+	if err != nil {
+		if cmd.timedOut() {
+			return output, fmt.Errorf("git %s: %w", cmd.Args[1], context.DeadlineExceeded)
 		}
-	} else if len(c.diffList) > 0 {
-		args = append(args, c.diffList...)
-	} else {
-		if c.commitId != "" {
-			args = append(args, c.commitId)
-		} else if c.isAmend {
-			args = append(args, "HEAD^", "HEAD")
-		} else {
-			//args = append(args, "--staged")
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
 		}
+		return output, err
 	}
+	return output, nil
+}
 
-	excludedFiles := c.excludeFiles()
-	args = append(args, excludedFiles...)
+// diffOutput is like output, but a nonzero exit status is only fatal when nothing was written,
+// since some git versions exit nonzero for certain diff configurations while producing a valid diff.
+func (c *Command) diffOutput(cmd *gitCommand) ([]byte, error) {
+	output, err := c.output(cmd)
+	if err != nil && len(output) == 0 {
+		return nil, err
+	}
+	return output, nil
+}
 
-	return exec.Command(
-		"git",
-		args...,
-	)
+// resolvePath resolves a path printed by git relative to the working directory.
+func (c *Command) resolvePath(p string) string {
+	p = strings.TrimSpace(p)
+	if c.workingDir == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(c.workingDir, p)
 }
 
-func (c *Command) hookPath() *exec.Cmd {
-	args := []string{
-		"rev-parse",
-		"--git-path",
-		"hooks",
+// CheckGit returns ErrGitNotFound, naming the searched PATH, when the configured git executable does not exist.
+// New runs it once for the exec backend; with the go-git backend, call it before committing.
+func (c *Command) CheckGit() error {
+	if _, err := exec.LookPath(c.gitBinary); err != nil {
+		return fmt.Errorf("%w: %s (PATH=%s): %v", ErrGitNotFound, c.gitBinary, os.Getenv("PATH"), err)
 	}
+	return nil
+}
 
-	return exec.Command(
-		"git",
-		args...,
-	)
+// checkWorkingDir verifies that the working directory exists and is inside a git work tree.
+func (c *Command) checkWorkingDir() error {
+	info, err := os.Stat(c.workingDir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("working directory %s is not a directory", c.workingDir)
+	}
+
+	// The go-git backend must work without the git binary.
+	if d, ok := c.diffBackend.(goGitDiffer); ok {
+		if _, err := d.open(); err != nil {
+			return fmt.Errorf("working directory %s is not inside a git work tree: %v", c.workingDir, err)
+		}
+		return nil
+	}
+
+	output, err := c.output(c.gitCmd(
+		context.Background(),
+		"rev-parse",
+		"--is-inside-work-tree",
+	))
+	if err != nil || strings.TrimSpace(string(output)) != "true" {
+		return fmt.Errorf("working directory %s is not inside a git work tree: %v", c.workingDir, err)
+	}
+	return nil
 }
 
-func (c *Command) gitDir() *exec.Cmd {
+func (c *Command) hookPath() *gitCommand {
 	args := []string{
 		"rev-parse",
-		"--git-dir",
+		"--git-path",
+		"hooks",
 	}
 
-	return exec.Command(
-		"git",
+	return c.gitCmd(
+		context.Background(),
 		args...,
 	)
 }
 
-func (c *Command) commit(val string) *exec.Cmd {
+func (c *Command) gitDir() *gitCommand {
 	args := []string{
-		"commit",
-		"--no-verify",
-		"--signoff",
-		fmt.Sprintf("--message=%s", val),
-	}
-
-	if c.isAmend {
-		args = append(args, "--amend")
+		"rev-parse",
+		"--git-dir",
 	}
 
-	return exec.Command(
-		"git",
+	return c.gitCmd(
+		context.Background(),
 		args...,
 	)
 }
 
-func (c *Command) Commit(val string) (string, error) {
-	output, err := c.commit(val).Output()
+// GitDir to show the (by default, absolute) path of the git directory of the working tree.
+// The returned path has no trailing newline.
+func (c *Command) GitDir() (string, error) {
+	output, err := c.output(c.gitDir())
 	if err != nil {
 		return "", err
 	}
 
-	return string(output), nil
+	return c.resolvePath(string(output)), nil
 }
 
-// GitDir to show the (by default, absolute) path of the git directory of the working tree.
-func (c *Command) GitDir() (string, error) {
-	output, err := c.gitDir().Output()
+// HooksDir returns the absolute path of the hooks directory, honoring core.hooksPath.
+func (c *Command) HooksDir() (string, error) {
+	output, err := c.output(c.hookPath())
 	if err != nil {
 		return "", err
 	}
 
-	return string(output), nil
+	return filepath.Abs(c.resolvePath(string(output)))
 }
 
 // Diff compares the differences between two sets of data.
 // It returns a string representing the differences and an error.
 // If there are no differences, it returns an empty string and an error.
 func (c *Command) DiffFiles() (string, error) {
-	output, err := c.diffNames().Output()
+	return c.DiffFilesContext(context.Background())
+}
+
+// DiffFilesContext is like DiffFiles but runs git with the given context,
+// so callers can cancel or enforce a timeout on the underlying git processes.
+func (c *Command) DiffFilesContext(ctx context.Context) (string, error) {
+	files, untracked, err := c.selectFiles(ctx)
 	if err != nil {
 		return "", err
 	}
-	if string(output) == "" {
-		return "", errors.New("please add your staged changes using git add <files...>")
+	if len(files) == 0 {
+		return "", ErrNoStagedChanges
 	}
 
-	output, err = c.diffFiles().Output()
+	var diff string
+	if untracked {
+		diff, err = c.untrackedDiff(ctx, files)
+	} else {
+		diff, err = c.diffBackend.diff(ctx)
+	}
 	if err != nil {
 		return "", err
 	}
+	if c.excludeGenerated {
+		changed := make(map[string]bool, len(files))
+		for _, f := range files {
+			changed[f] = true
+		}
+		diff = filterPatches(diff, func(path string) bool { return changed[path] })
+	}
+	if c.omitBinary {
+		binary, err := c.binaryFiles(ctx)
+		if err != nil {
+			return "", err
+		}
+		diff = filterPatches(diff, func(path string) bool { return !binary[path] })
+	}
+	diff = omitLargePatches(diff, c.maxPatchBytes)
+	return truncateDiff(diff, c.maxDiffBytes), nil
+}
+
+// ChangedFiles returns the paths of the files changed in the diff, honoring the active range and excludes.
+func (c *Command) ChangedFiles() ([]string, error) {
+	return c.changedFiles(context.Background())
+}
+
+func (c *Command) changedFiles(ctx context.Context) ([]string, error) {
+	files, _, err := c.selectFiles(ctx)
+	return files, err
+}
+
+// selectFiles returns the changed files. When there are none, includeUntracked is set
+// and the working tree is being compared, it returns the untracked files instead and reports so.
+func (c *Command) selectFiles(ctx context.Context) (files []string, untracked bool, err error) {
+	files, err = c.diffBackend.changedFiles(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	if c.excludeGenerated {
+		files = c.dropGenerated(ctx, files)
+	}
+	if len(files) > 0 || !c.includeUntracked {
+		return files, false, nil
+	}
 
-	return string(output), nil
+	// Untracked files only belong in a diff of the working tree, or of the index before the first commit.
+	subcommand, revs, err := c.diffRange(ctx)
+	if err != nil || (len(revs) > 0 && subcommand[len(subcommand)-1] != "--cached") {
+		return files, false, err
+	}
+	files, err = c.untrackedFiles(ctx)
+	return files, true, err
 }
 
-func (c *Command) InstallHook() error {
-	hookPath, err := c.hookPath().Output()
+// DiffCommandString returns the git diff command line that DiffFiles would run,
+// quoted for a POSIX shell, for logging and bug reports.
+// Resolving the range may run git, for example to find the latest tags; if that fails,
+// the error is returned as the string so it still shows up in logs.
+func (c *Command) DiffCommandString() string {
+	cmd, err := c.diffFiles(context.Background())
 	if err != nil {
-		return err
+		return "error: " + err.Error()
+	}
+	return quoteCommand(cmd.Args)
+}
+
+// HasStagedChanges reports whether the index differs from HEAD, regardless of the configured range and excludes.
+func (c *Command) HasStagedChanges() (bool, error) {
+	_, err := c.output(c.gitCmd(
+		context.Background(),
+		"diff",
+		"--cached",
+		"--quiet",
+	))
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return false, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return true, nil
+	default:
+		return false, err
 	}
+}
 
-	target := path.Join(strings.TrimSpace(string(hookPath)), HookPrepareCommitMessageTemplate)
-	if file.IsFile(target) {
-		return errors.New("hook file prepare-commit-msg exist.")
+// splitNUL splits the NUL-terminated fields git prints with -z. Paths in them are never quoted,
+// so names with non-ASCII or special characters come back as they are.
+func splitNUL(output string) []string {
+	fields := strings.Split(output, "\x00")
+	if n := len(fields); fields[n-1] == "" {
+		fields = fields[:n-1]
 	}
+	return fields
+}
+
+// splitLines splits output into trimmed lines, dropping empty ones.
+func splitLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
 
-	content, err := util.GetTemplateByBytes(HookPrepareCommitMessageTemplate, nil)
+// DiffFilesByPath returns the same diff as DiffFiles split per file and keyed by file path,
+// so callers can chunk large diffs. Each value keeps the file's header and all of its hunks.
+func (c *Command) DiffFilesByPath() (map[string]string, error) {
+	diff, err := c.DiffFiles()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return os.WriteFile(target, content, 0o755)
+	files := make(map[string]string)
+	for _, p := range splitPatches(diff) {
+		files[p.path] += p.patch
+	}
+	return files, nil
 }
 
-func (c *Command) UninstallHook() error {
-	hookPath, err := c.hookPath().Output()
+// DiffByTopDir returns the same diff as DiffFiles grouped by the first segment of each file's path,
+// so changes to each component of a monorepo can be summarized separately.
+// Files at the repository root are grouped under ".".
+func (c *Command) DiffByTopDir() (map[string]string, error) {
+	diff, err := c.DiffFiles()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	target := path.Join(strings.TrimSpace(string(hookPath)), HookPrepareCommitMessageTemplate)
-	if !file.IsFile(target) {
-		return errors.New("hook file prepare-commit-msg is not exist.")
+	dirs := make(map[string]string)
+	for _, p := range splitPatches(diff) {
+		dir := "."
+		if i := strings.IndexByte(p.path, '/'); i != -1 {
+			dir = p.path[:i]
+		}
+		dirs[dir] += p.patch
 	}
-	return os.Remove(target)
+	return dirs, nil
 }
 
-func New(opts ...Option) *Command {
-	// Instantiate a new config object with default values
-	cfg := &config{}
+func New(opts ...Option) (*Command, error) {
+	// Instantiate a new config object with default values and apply the options to it
+	cfg := newConfig(opts...)
 
-	// Loop through each option passed as argument and apply it to the config object
-	for _, o := range opts {
-		o.apply(cfg)
+	// Validate the config object, returning an error if it is invalid
+	if err := cfg.valid(); err != nil {
+		return nil, err
 	}
 
 	// Instantiate a new Command object with the configurations from the config object
 	cmd := &Command{
-		diffUnified: cfg.diffUnified,
-		// Append the user-defined excludeList to the default excludeFromDiff
-		excludeList:   append(excludeFromDiff, cfg.excludeList...),
+		diffUnified:   cfg.diffUnified,
+		includeList:   append([]string(nil), cfg.includeList...),
+		excludeList:   append([]string(nil), cfg.excludeList...),
 		isAmend:       cfg.isAmend,
+		lastCommit:    cfg.lastCommit,
 		diffTagPrefix: cfg.diffTagPrefix,
 		commitId:      cfg.commitId,
-		diffList:      cfg.diffList,
+		mergeCommit:   cfg.mergeCommit,
+		mergeParent:   cfg.mergeParent,
+		diffList:      append([]string(nil), cfg.diffList...),
+		commitFrom:    cfg.commitFrom,
+		commitTo:      cfg.commitTo,
+		baseBranch:    cfg.baseBranch,
+		headBranch:    cfg.headBranch,
+		upstream:      cfg.upstream,
+		tagToHead:     cfg.tagToHead,
+		stashRef:      cfg.stashRef,
+		excludeMagic:  cfg.excludeMagic,
+		tagSort:       cfg.tagSort,
+		tagPattern:    cfg.tagPattern,
+
+		renameThreshold:  cfg.renameThreshold,
+		detectCopies:     cfg.detectCopies,
+		rangeRenames:     cfg.rangeRenames,
+		firstParent:      cfg.firstParent,
+		wordDiff:         cfg.wordDiff,
+		diffAlgorithm:    cfg.diffAlgorithm,
+		whitespaceMode:   cfg.whitespaceMode,
+		gitBinary:        cfg.gitBinary,
+		env:              append([]string(nil), cfg.env...),
+		workingDir:       cfg.workingDir,
+		noVerify:         cfg.noVerify,
+		signoff:          cfg.signoff,
+		allowEmpty:       cfg.allowEmpty,
+		skipIfEmpty:      cfg.skipIfEmpty,
+		normalizeMessage: cfg.normalizeMessage,
+		maxSubjectLength: cfg.maxSubjectLength,
+		cleanupMode:      cfg.cleanupMode,
+		signCommit:       cfg.signCommit,
+		signingKey:       cfg.signingKey,
+		authorName:       cfg.authorName,
+		authorEmail:      cfg.authorEmail,
+		coAuthors:        append([]string(nil), cfg.coAuthors...),
+		trailers:         make(map[string]string, len(cfg.trailers)),
+		commitDate:       cfg.commitDate,
+		forceHook:        cfg.forceHook,
+		maxDiffBytes:     cfg.maxDiffBytes,
+		maxPatchBytes:    cfg.maxPatchBytes,
+		excludeGenerated: cfg.excludeGenerated,
+		omitBinary:       cfg.omitBinary,
+		includeUntracked: cfg.includeUntracked,
+		functionContext:  cfg.functionContext,
+		textConv:         cfg.textConv,
+		color:            cfg.color,
+		submoduleMode:    cfg.submoduleMode,
+		retryAttempts:    cfg.retryAttempts,
+		retryDelay:       cfg.retryDelay,
+		diffFilter:       cfg.diffFilter,
+		nameStatus:       cfg.nameStatus,
+		extraDiffArgs:    append([]string(nil), cfg.extraDiffArgs...),
+		timeout:          cfg.timeout,
 	}
 
-	return cmd
+	for key, value := range cfg.trailers {
+		cmd.trailers[key] = value
+	}
+
+	for _, ext := range cfg.extensions {
+		cmd.extensions = append(cmd.extensions, "."+strings.TrimPrefix(ext, "."))
+	}
+
+	if cfg.onlyRenames {
+		cmd.diffFilter = "RC"
+		if cmd.renameThreshold == 0 {
+			cmd.renameThreshold = defaultRenameThreshold
+		}
+	}
+
+	if !cfg.replaceDefaultExcludes && !cfg.noDefaultExcludes {
+		// Append the user-defined excludeList to the default excludeFromDiff
+		cmd.excludeList = append(DefaultExcludeFromDiff(), cfg.excludeList...)
+	}
+
+	if cfg.excludeFile != "" {
+		patterns, err := readExcludeFile(cmd.resolvePath(cfg.excludeFile))
+		if err != nil {
+			return nil, err
+		}
+		cmd.excludeList = append(cmd.excludeList, patterns...)
+	}
+
+	if cfg.tagPattern != "" && cfg.tagRegex {
+		// The pattern was already checked by valid.
+		cmd.tagRegexp = regexp.MustCompile(cfg.tagPattern)
+	}
+
+	if cfg.backend == BackendGoGit {
+		cmd.diffBackend = goGitDiffer{cmd}
+	} else {
+		cmd.diffBackend = execDiffer{cmd}
+	}
+
+	// The go-git backend computes diffs without git, so a missing binary only matters once committing.
+	if cfg.backend != BackendGoGit {
+		if err := cmd.CheckGit(); err != nil {
+			return nil, err
+		}
+	}
+
+	if cmd.workingDir != "" {
+		if err := cmd.checkWorkingDir(); err != nil {
+			return nil, err
+		}
+	}
+
+	return cmd, nil
 }
@@ -0,0 +1,1441 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// setupRepo creates an empty git repository in a temporary directory
+// and changes the working directory into it for the duration of the test.
+func setupRepo(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(wd)
+	})
+
+	runGit(t, "init", "-q", "-b", "main")
+	runGit(t, "config", "user.name", "tester")
+	runGit(t, "config", "user.email", "tester@example.com")
+	runGit(t, "config", "commit.gpgsign", "false")
+
+	return dir
+}
+
+// runGit runs a git command in the current directory and returns its trimmed output.
+func runGit(t *testing.T, args ...string) string {
+	t.Helper()
+
+	output, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// writeFile writes content to name, creating parent directories as needed.
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// commitFile writes and commits a single file.
+func commitFile(t *testing.T, name, content, message string) {
+	t.Helper()
+
+	writeFile(t, name, content)
+	runGit(t, "add", name)
+	runGit(t, "commit", "-q", "-m", message)
+}
+
+// mustNew returns a new Command, failing the test if the options are invalid.
+func mustNew(t *testing.T, opts ...Option) *Command {
+	t.Helper()
+
+	g, err := New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// diffNames runs the name-only diff command of g.
+func diffNames(t *testing.T, g *Command) ([]byte, error) {
+	t.Helper()
+
+	cmd, err := g.diffNames(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	output, err := g.diffOutput(cmd)
+	// one name per line, so tests can split the output with strings.Fields
+	return bytes.ReplaceAll(output, []byte{0}, []byte{'\n'}), err
+}
+
+func TestCommitRange(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	commitFile(t, "b.txt", "b\n", "second")
+	commitFile(t, "c.txt", "c\n", "third")
+
+	tests := []struct {
+		name     string
+		from, to string
+		want     []string
+	}{
+		{
+			name: "explicit range",
+			from: "HEAD~2",
+			to:   "HEAD",
+			want: []string{"b.txt", "c.txt"},
+		},
+		{
+			name: "range to HEAD",
+			from: "HEAD~1",
+			want: []string{"c.txt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := mustNew(t, WithCommitRange(tt.from, tt.to))
+
+			output, err := diffNames(t, g)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Fields(string(output)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffNames() = %v, want %v", got, tt.want)
+			}
+
+			diff, err := g.DiffFiles()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range tt.want {
+				if !strings.Contains(diff, "+++ b/"+name) {
+					t.Errorf("DiffFiles() missing %s:\n%s", name, diff)
+				}
+			}
+			if strings.Contains(diff, "a.txt") {
+				t.Errorf("DiffFiles() should not contain a.txt:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommitRangePrecedence(t *testing.T) {
+	g := mustNew(t,
+		WithCommitRange("v1", "v2"),
+		WithDiffTagPrefix("release"),
+		WithEnableAmend(true),
+	)
+	if _, got, _ := g.diffRange(context.Background()); !reflect.DeepEqual(got, []string{"v1", "v2"}) {
+		t.Errorf("diffRange() = %v, want %v", got, []string{"v1", "v2"})
+	}
+}
+
+func TestCommitId(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	root := runGit(t, "rev-parse", "HEAD")
+	commitFile(t, "b.txt", "b\n", "second")
+	second := runGit(t, "rev-parse", "HEAD")
+	commitFile(t, "c.txt", "c\n", "third")
+
+	tests := []struct {
+		name     string
+		commitId string
+		want     []string
+	}{
+		{
+			name:     "normal commit",
+			commitId: second,
+			want:     []string{"b.txt"},
+		},
+		{
+			name:     "initial commit",
+			commitId: root,
+			want:     []string{"a.txt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := mustNew(t, WithCommitId(tt.commitId))
+
+			output, err := diffNames(t, g)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Fields(string(output)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffNames() = %v, want %v", got, tt.want)
+			}
+
+			diff, err := g.DiffFiles()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(diff, "+++ b/"+tt.want[0]) {
+				t.Errorf("DiffFiles() missing %s:\n%s", tt.want[0], diff)
+			}
+		})
+	}
+}
+
+func TestEmptyTreeSHA(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "b.txt", "line 1\nline 2\n")
+	runGit(t, "add", "b.txt")
+	runGit(t, "commit", "-q", "--amend", "-m", "first")
+
+	g := mustNew(t)
+	tree, err := g.EmptyTreeSHA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree != emptyTree {
+		t.Errorf("EmptyTreeSHA() = %q, want %q", tree, emptyTree)
+	}
+
+	for _, opt := range []Option{WithCommitId("HEAD"), WithLastCommit(true)} {
+		diff, err := mustNew(t, opt, WithDiffUnified(3)).DiffFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := runGit(t, "show", "--format=", "--ignore-all-space", "--diff-algorithm=minimal", "--unified=3", "HEAD")
+		if strings.TrimSpace(diff) != want {
+			t.Errorf("DiffFiles() =\n%s\nwant git show output\n%s", diff, want)
+		}
+	}
+}
+
+func TestWithMergeCommit(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "base.txt", "base\n", "base")
+	runGit(t, "checkout", "-q", "-b", "feature")
+	commitFile(t, "feature.txt", "feature\n", "feature")
+	commitFile(t, "feature2.txt", "feature\n", "feature 2")
+	runGit(t, "checkout", "-q", "main")
+	commitFile(t, "main.txt", "main\n", "main")
+	runGit(t, "merge", "-q", "--no-ff", "-m", "merge feature", "feature")
+	merge := runGit(t, "rev-parse", "HEAD")
+
+	tests := []struct {
+		name   string
+		parent int
+		want   []string
+	}{
+		{name: "first parent", parent: 1, want: []string{"feature.txt", "feature2.txt"}},
+		{name: "second parent", parent: 2, want: []string{"main.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := diffNames(t, mustNew(t, WithMergeCommit(merge), WithMergeParent(tt.parent)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Fields(string(output)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := mustNew(t, WithMergeCommit("HEAD^1")).DiffFiles(); !errors.Is(err, ErrNotMergeCommit) {
+		t.Errorf("DiffFiles() error = %v, want %v", err, ErrNotMergeCommit)
+	}
+	if _, err := mustNew(t, WithMergeCommit(merge), WithMergeParent(3)).DiffFiles(); err == nil || !strings.Contains(err.Error(), "^3") {
+		t.Errorf("DiffFiles() error = %v, want the missing parent named", err)
+	}
+	if _, err := New(WithMergeParent(0)); !errors.Is(err, errorsInvalidMergeParent) {
+		t.Errorf("New() error = %v, want %v", err, errorsInvalidMergeParent)
+	}
+}
+
+func TestBranches(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "base.txt", "base\n", "base")
+	runGit(t, "branch", "uptodate")
+	runGit(t, "checkout", "-q", "-b", "feature")
+	commitFile(t, "feature.txt", "feature\n", "feature")
+	runGit(t, "checkout", "-q", "main")
+	commitFile(t, "main.txt", "main\n", "main")
+
+	tests := []struct {
+		name    string
+		head    string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "diverged branch",
+			head: "feature",
+			want: []string{"feature.txt"},
+		},
+		{
+			name: "up-to-date branch",
+			head: "uptodate",
+			want: []string{},
+		},
+		{
+			name:    "missing branch",
+			head:    "missing",
+			wantErr: "missing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := diffNames(t, mustNew(t, WithBranches("main", tt.head)))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("diffNames() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Fields(string(output)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithUpstream(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "base.txt", "base\n", "base")
+	runGit(t, "checkout", "-q", "-b", "feature", "--track", "main")
+	commitFile(t, "feature.txt", "feature\n", "feature")
+	runGit(t, "checkout", "-q", "main")
+	commitFile(t, "main.txt", "main\n", "main")
+	runGit(t, "checkout", "-q", "feature")
+
+	output, err := diffNames(t, mustNew(t, WithUpstream(true)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Fields(string(output)), []string{"feature.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("diffNames() = %v, want %v", got, want)
+	}
+
+	runGit(t, "checkout", "-q", "-b", "local")
+	if _, err := mustNew(t, WithUpstream(true)).DiffFiles(); !errors.Is(err, ErrNoUpstream) {
+		t.Errorf("DiffFiles() error = %v, want %v", err, ErrNoUpstream)
+	}
+}
+
+func TestWithLastCommit(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	commitFile(t, "b.txt", "b\n", "second")
+
+	g := mustNew(t, WithLastCommit(true))
+	_, revs, err := g.diffRange(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"HEAD^", "HEAD"}; !reflect.DeepEqual(revs, want) {
+		t.Errorf("diffRange() revs = %v, want %v", revs, want)
+	}
+	if args := g.commit("next").Args; containsArg(args, "--amend") {
+		t.Errorf("commit() args = %v, want no --amend", args)
+	}
+}
+
+func TestConcurrentUse(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "a.txt", "b\n")
+	writeFile(t, "b.txt", "b\n")
+	runGit(t, "add", "b.txt")
+
+	excludes := []string{"c.txt"}
+	g := mustNew(t, WithExcludeList(excludes))
+	want, err := g.DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Options are copied by New, so changing the caller's slice affects nothing.
+	excludes[0] = "a.txt"
+
+	const goroutines = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines)
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			diff, err := g.DiffFiles()
+			if err == nil && diff != want {
+				err = fmt.Errorf("DiffFiles() = %q, want %q", diff, want)
+			}
+			if err == nil {
+				_, err = g.ChangedFiles()
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestErrNoStagedChanges(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+
+	_, err := mustNew(t).DiffFiles()
+	if !errors.Is(err, ErrNoStagedChanges) {
+		t.Errorf("DiffFiles() error = %v, want %v", err, ErrNoStagedChanges)
+	}
+}
+
+func TestNoCommitsYet(t *testing.T) {
+	setupRepo(t)
+	writeFile(t, "a.txt", "hello\n")
+	writeFile(t, "b.txt", "untracked\n")
+	runGit(t, "add", "a.txt")
+
+	g := mustNew(t)
+	files, err := g.ChangedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.txt"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("ChangedFiles() = %v, want %v", files, want)
+	}
+
+	diff, err := g.DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"new file mode", "+++ b/a.txt", "+hello"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("DiffFiles() missing %q:\n%s", want, diff)
+		}
+	}
+
+	if _, err := mustNew(t, WithEnableAmend(true)).DiffFiles(); !errors.Is(err, ErrNoCommitsYet) {
+		t.Errorf("amend DiffFiles() error = %v, want %v", err, ErrNoCommitsYet)
+	}
+	if _, err := g.LastCommitMessage(); !errors.Is(err, ErrNoCommitsYet) {
+		t.Errorf("LastCommitMessage() error = %v, want %v", err, ErrNoCommitsYet)
+	}
+}
+
+func TestHasCommitsError(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "a.txt", "b\n")
+
+	// Failures other than an unresolvable HEAD must not be mistaken for an empty repository.
+	binary := fakeGit(t, `[ "$1" = rev-parse ] && [ "$2" = --verify ] && echo "fatal: broken" >&2 && exit 128`)
+	g := mustNew(t, WithGitBinary(binary))
+	if _, err := g.DiffFiles(); err == nil || errors.Is(err, ErrNoCommitsYet) || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("DiffFiles() error = %v, want git's error", err)
+	}
+	if _, err := g.LastCommitMessage(); err == nil || errors.Is(err, ErrNoCommitsYet) {
+		t.Errorf("LastCommitMessage() error = %v, want git's error", err)
+	}
+	if _, err := g.IsHeadPushed(); err == nil || errors.Is(err, ErrNoCommitsYet) {
+		t.Errorf("IsHeadPushed() error = %v, want git's error", err)
+	}
+}
+
+func TestAmendRootCommit(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "hello\n", "first")
+
+	for _, backend := range []Backend{BackendExec, BackendGoGit} {
+		diff, err := mustNew(t, WithEnableAmend(true), WithBackend(backend), WithWhitespaceMode("none")).DiffFiles()
+		if err != nil {
+			t.Fatalf("%s: %v", backend, err)
+		}
+		for _, want := range []string{"new file mode", "+++ b/a.txt", "+hello"} {
+			if !strings.Contains(diff, want) {
+				t.Errorf("%s: amend DiffFiles() missing %q:\n%s", backend, want, diff)
+			}
+		}
+	}
+
+	// The diff describes the whole root commit, so reverting it must apply cleanly.
+	diff, err := mustNew(t, WithEnableAmend(true)).DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch := filepath.Join(t.TempDir(), "root.patch")
+	if err := os.WriteFile(patch, []byte(diff), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, "apply", "--check", "--reverse", patch)
+}
+
+func TestDiffFilesContextCanceled(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "a.txt", "b\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := mustNew(t).DiffFilesContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("DiffFilesContext() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestExcludeGlob(t *testing.T) {
+	setupRepo(t)
+	for _, name := range []string{"a.go", "sub/a.go", "vendor/lib/lib.go", "pkg/api.generated.go", "pkg/api.go"} {
+		writeFile(t, name, "package a\n")
+	}
+	runGit(t, "add", ".")
+	runGit(t, "commit", "-q", "-m", "init")
+	for _, name := range []string{"a.go", "sub/a.go", "vendor/lib/lib.go", "pkg/api.generated.go", "pkg/api.go"} {
+		writeFile(t, name, "package b\n")
+	}
+
+	tests := []struct {
+		name    string
+		exclude []string
+		want    []string
+	}{
+		{
+			name:    "double star directory",
+			exclude: []string{"vendor/**"},
+			want:    []string{"a.go", "pkg/api.generated.go", "pkg/api.go", "sub/a.go"},
+		},
+		{
+			name:    "double star suffix",
+			exclude: []string{"**/*.generated.go"},
+			want:    []string{"a.go", "pkg/api.go", "sub/a.go", "vendor/lib/lib.go"},
+		},
+		{
+			name:    "literal path",
+			exclude: []string{"a.go"},
+			want:    []string{"pkg/api.generated.go", "pkg/api.go", "sub/a.go", "vendor/lib/lib.go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := diffNames(t, mustNew(t, WithExcludeList(tt.exclude)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Fields(string(output)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExcludePathspecMagic(t *testing.T) {
+	g := mustNew(t, WithReplaceDefaultExcludes(true), WithExcludeList([]string{"README.MD", "docs/**"}),
+		WithExcludePathspecMagic("exclude,top,icase"))
+	want := []string{":(exclude,top,icase)README.MD", ":(exclude,top,icase,glob)docs/**"}
+	if got := g.excludeFiles(); !reflect.DeepEqual(got, want) {
+		t.Errorf("excludeFiles() = %v, want %v", got, want)
+	}
+
+	setupRepo(t)
+	commitFile(t, "README.md", "a\n", "readme")
+	commitFile(t, "main.go", "a\n", "main")
+	writeFile(t, "README.md", "b\n")
+	writeFile(t, "main.go", "b\n")
+
+	files, err := g.ChangedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"main.go"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("ChangedFiles() = %v, want %v", files, want)
+	}
+
+	for _, magic := range []string{"top", "exclude,bogus", "exclude,glob,literal", "exclude,"} {
+		if _, err := New(WithExcludePathspecMagic(magic)); !errors.Is(err, errorsInvalidExcludeMagic) {
+			t.Errorf("New(%q) error = %v, want %v", magic, err, errorsInvalidExcludeMagic)
+		}
+	}
+}
+
+func TestReplaceDefaultExcludes(t *testing.T) {
+	setupRepo(t)
+	for _, name := range []string{"main.go", "go.sum", "yarn.lock", "notes.txt"} {
+		writeFile(t, name, "a\n")
+	}
+	runGit(t, "add", ".")
+	runGit(t, "commit", "-q", "-m", "init")
+	for _, name := range []string{"main.go", "go.sum", "yarn.lock", "notes.txt"} {
+		writeFile(t, name, "b\n")
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{
+			name: "defaults",
+			want: []string{"main.go", "notes.txt"},
+		},
+		{
+			name: "append",
+			opts: []Option{WithExcludeList([]string{"notes.txt"})},
+			want: []string{"main.go"},
+		},
+		{
+			name: "replace",
+			opts: []Option{WithExcludeList([]string{"notes.txt"}), WithReplaceDefaultExcludes(true)},
+			want: []string{"go.sum", "main.go", "yarn.lock"},
+		},
+		{
+			name: "replace with nothing",
+			opts: []Option{WithReplaceDefaultExcludes(true)},
+			want: []string{"go.sum", "main.go", "notes.txt", "yarn.lock"},
+		},
+		{
+			name: "no defaults",
+			opts: []Option{WithNoDefaultExcludes()},
+			want: []string{"go.sum", "main.go", "notes.txt", "yarn.lock"},
+		},
+		{
+			name: "no defaults with user excludes",
+			opts: []Option{WithNoDefaultExcludes(), WithExcludeList([]string{"notes.txt"})},
+			want: []string{"go.sum", "main.go", "yarn.lock"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := diffNames(t, mustNew(t, tt.opts...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Fields(string(output)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultExcludeFromDiff(t *testing.T) {
+	excludes := DefaultExcludeFromDiff()
+	if !reflect.DeepEqual(excludes, excludeFromDiff) {
+		t.Fatalf("DefaultExcludeFromDiff() = %v, want %v", excludes, excludeFromDiff)
+	}
+
+	excludes[0] = "changed"
+	_ = append(excludes, "appended")
+	if again := DefaultExcludeFromDiff(); excludes[0] == again[0] || len(again) != len(excludeFromDiff) {
+		t.Errorf("mutating the returned slice changed the default: %v", again)
+	}
+}
+
+func TestDiffFilter(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "modified.txt", "a\n", "add modified")
+	commitFile(t, "deleted.txt", "old\n", "add deleted")
+	writeFile(t, "modified.txt", "b\n")
+	writeFile(t, "added.txt", "new\n")
+	runGit(t, "rm", "-q", "deleted.txt")
+	runGit(t, "add", "-A")
+	runGit(t, "commit", "-q", "-m", "mixed")
+
+	tests := []struct {
+		filter string
+		want   []string
+	}{
+		{filter: "", want: []string{"added.txt", "deleted.txt", "modified.txt"}},
+		{filter: "A", want: []string{"added.txt"}},
+		{filter: "D", want: []string{"deleted.txt"}},
+		{filter: "d", want: []string{"added.txt", "modified.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run("filter "+tt.filter, func(t *testing.T) {
+			g := mustNew(t, WithCommitId("HEAD"), WithDiffFilter(tt.filter))
+			files, err := g.ChangedFiles()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(files, tt.want) {
+				t.Errorf("ChangedFiles() = %v, want %v", files, tt.want)
+			}
+
+			diff, err := g.DiffFiles()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(splitPatches(diff)); got != len(tt.want) {
+				t.Errorf("DiffFiles() has %d patches, want %d:\n%s", got, len(tt.want), diff)
+			}
+		})
+	}
+
+	if _, err := New(WithDiffFilter("AZ")); !errors.Is(err, errorsInvalidDiffFilter) {
+		t.Errorf("New() error = %v, want %v", err, errorsInvalidDiffFilter)
+	}
+}
+
+func TestIncludeList(t *testing.T) {
+	setupRepo(t)
+	for _, name := range []string{"main.go", "pkg/a.go", "pkg/a_gen.go", "src/b.go"} {
+		writeFile(t, name, "package a\n")
+	}
+	runGit(t, "add", ".")
+	runGit(t, "commit", "-q", "-m", "init")
+	for _, name := range []string{"main.go", "pkg/a.go", "pkg/a_gen.go", "src/b.go"} {
+		writeFile(t, name, "package b\n")
+	}
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    []string
+	}{
+		{
+			name:    "include only",
+			include: []string{"pkg/"},
+			want:    []string{"pkg/a.go", "pkg/a_gen.go"},
+		},
+		{
+			name:    "exclude within include",
+			include: []string{"pkg/"},
+			exclude: []string{"pkg/a_gen.go"},
+			want:    []string{"pkg/a.go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := diffNames(t, mustNew(t,
+				WithIncludeList(tt.include),
+				WithExcludeList(tt.exclude),
+			))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Fields(string(output)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffList(t *testing.T) {
+	setupRepo(t)
+	for _, name := range []string{"main.go", "pkg/a.go", "src/*.go", "src/c.go"} {
+		writeFile(t, name, "package a\n")
+	}
+	runGit(t, "add", ".")
+	runGit(t, "commit", "-q", "-m", "init")
+	for _, name := range []string{"main.go", "pkg/a.go", "src/*.go", "src/c.go"} {
+		writeFile(t, name, "package b\n")
+	}
+	runGit(t, "commit", "-q", "-am", "change")
+
+	for _, backend := range []Backend{BackendExec, BackendGoGit} {
+		t.Run(string(backend), func(t *testing.T) {
+			files, err := mustNew(t,
+				WithBackend(backend),
+				WithCommitRange("HEAD~1", "HEAD"),
+				WithDiffList([]string{"main.go", "src/*.go"}),
+			).ChangedFiles()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []string{"main.go", "src/*.go"}; !reflect.DeepEqual(files, want) {
+				t.Errorf("ChangedFiles() = %v, want %v", files, want)
+			}
+		})
+	}
+}
+
+func TestWithExtraDiffArgs(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "a.txt", "b\n")
+
+	g := mustNew(t, WithExtraDiffArgs([]string{"--color=never", "--inter-hunk-context", "2"}))
+	cmd, err := g.diffFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := strings.Join(cmd.Args, " ")
+	if !strings.Contains(args, "--color=never --inter-hunk-context 2 :(exclude,top)") {
+		t.Errorf("diffFiles() args = %q, want the extra arguments before the pathspecs", args)
+	}
+	if diff, err := g.DiffFiles(); err != nil || !strings.Contains(diff, "+b") {
+		t.Errorf("DiffFiles() = %q, %v, want the change", diff, err)
+	}
+
+	for _, extra := range [][]string{
+		{"a.txt"},
+		{"--color=never", "--", "a.txt"},
+		{"--anchored"},
+		{"-S", "b"},
+		{"--pickaxe-regex"},
+		{"--output=/tmp/diff"},
+		{"--cached"},
+		{"--no-index"},
+		{"--ext-diff"},
+		{"--color=always"},
+		{"--name-only"},
+		{"--minimal=yes"},
+		{"-bw"},
+	} {
+		if _, err := New(WithExtraDiffArgs(extra)); !errors.Is(err, errorsInvalidExtraDiffArg) {
+			t.Errorf("New(%q) error = %v, want %v", extra, err, errorsInvalidExtraDiffArg)
+		}
+	}
+
+	for _, extra := range [][]string{
+		{"--diff-algorithm=patience", "-Ifoo", "-I", "bar"},
+		{"--inter-hunk-context=3", "-w", "--anchored", "text"},
+	} {
+		if _, err := New(WithExtraDiffArgs(extra)); err != nil {
+			t.Errorf("New(%q) error = %v, want nil", extra, err)
+		}
+	}
+}
+
+func TestWithTextConv(t *testing.T) {
+	setupRepo(t)
+	runGit(t, "config", "diff.upper.textconv", "tr a-z A-Z <")
+	writeFile(t, ".gitattributes", "*.dat diff=upper\n")
+	commitFile(t, "a.dat", "old\n", "first")
+	writeFile(t, "a.dat", "new\n")
+
+	g := mustNew(t, WithTextConv(true))
+	cmd, err := g.diffFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !containsArg(cmd.Args, "--textconv") {
+		t.Errorf("diffFiles() args = %v, want --textconv", cmd.Args)
+	}
+	diff, err := g.DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "-OLD\n") || !strings.Contains(diff, "+NEW\n") {
+		t.Errorf("DiffFiles() =\n%s\nwant the converted text", diff)
+	}
+
+	// Without the option, git's default applies, which runs textconv drivers for git diff.
+	g = mustNew(t, WithTextConv(false))
+	cmd, err = g.diffFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if containsArg(cmd.Args, "--textconv") || containsArg(cmd.Args, "--no-textconv") {
+		t.Errorf("diffFiles() args = %v, want no textconv flag", cmd.Args)
+	}
+	diff, err = g.DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "-OLD\n") || !strings.Contains(diff, "+NEW\n") {
+		t.Errorf("DiffFiles() =\n%s\nwant git's default textconv output", diff)
+	}
+}
+
+func TestWithColor(t *testing.T) {
+	setupRepo(t)
+	runGit(t, "config", "color.diff", "always")
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "a.txt", "b\n")
+
+	g := mustNew(t)
+	cmd, err := g.diffFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !containsArg(cmd.Args, "--color=never") {
+		t.Errorf("diffFiles() args = %v, want --color=never", cmd.Args)
+	}
+	diff, err := g.DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(diff, "\x1b[") {
+		t.Errorf("DiffFiles() = %q, want no ANSI escapes", diff)
+	}
+
+	diff, err = mustNew(t, WithColor(true)).DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "\x1b[") {
+		t.Errorf("DiffFiles() = %q, want ANSI escapes", diff)
+	}
+}
+
+func TestExtraDiffArgsAlgorithm(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "a.txt", "b\n")
+
+	tests := []struct {
+		extra []string
+		want  []string
+	}{
+		{extra: nil, want: []string{"--diff-algorithm=minimal"}},
+		{extra: []string{"--diff-algorithm=histogram"}, want: []string{"--diff-algorithm=histogram"}},
+		{extra: []string{"--diff-algorithm", "patience"}, want: []string{"--diff-algorithm"}},
+		{extra: []string{"--patience"}, want: nil},
+	}
+	for _, tt := range tests {
+		g := mustNew(t, WithExtraDiffArgs(tt.extra))
+		cmd, err := g.diffFiles(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, arg := range cmd.Args {
+			if strings.HasPrefix(arg, "--diff-algorithm") {
+				got = append(got, arg)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("diffFiles(%q) algorithm args = %q, want %q", tt.extra, got, tt.want)
+		}
+		if _, err := g.DiffFiles(); err != nil {
+			t.Errorf("DiffFiles(%q) error = %v", tt.extra, err)
+		}
+	}
+}
+
+func TestDetectRenames(t *testing.T) {
+	setupRepo(t)
+	content := "line 1\nline 2\nline 3\nline 4\nline 5\nline 6\nline 7\nline 8\n"
+	commitFile(t, "old.txt", content, "add")
+	runGit(t, "mv", "old.txt", "new.txt")
+	commitFile(t, "new.txt", strings.Replace(content, "line 8", "line eight", 1), "rename")
+
+	g := mustNew(t,
+		WithCommitRange("HEAD~1", "HEAD"),
+		WithDetectRenames(0),
+	)
+	cmd, err := g.diffFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(strings.Join(cmd.Args, " "), "-M50%") {
+		t.Errorf("diffFiles() args = %v, want -M50%%", cmd.Args)
+	}
+
+	diff, err := g.DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "rename from old.txt") || !strings.Contains(diff, "rename to new.txt") {
+		t.Errorf("DiffFiles() missing rename header:\n%s", diff)
+	}
+	if strings.Contains(diff, "deleted file mode") {
+		t.Errorf("DiffFiles() should not delete old.txt:\n%s", diff)
+	}
+}
+
+func TestWithOnlyRenames(t *testing.T) {
+	setupRepo(t)
+	content := "line 1\nline 2\nline 3\nline 4\nline 5\nline 6\nline 7\nline 8\n"
+	commitFile(t, "old.txt", content, "add")
+	commitFile(t, "other.txt", "a\n", "other")
+	runGit(t, "mv", "old.txt", "new.txt")
+	writeFile(t, "other.txt", "b\n")
+	writeFile(t, "added.txt", "c\n")
+	runGit(t, "add", ".")
+	runGit(t, "commit", "-q", "-m", "refactor")
+
+	g := mustNew(t, WithLastCommit(true), WithOnlyRenames(true))
+	files, err := g.ChangedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"new.txt"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("ChangedFiles() = %v, want %v", files, want)
+	}
+	diff, err := g.DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "rename from old.txt\nrename to new.txt\n") || strings.Contains(diff, "other.txt") {
+		t.Errorf("DiffFiles() =\n%s\nwant only the rename", diff)
+	}
+}
+
+func TestNonASCIIPaths(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "café.txt", "a\n", "init")
+	commitFile(t, "old name.txt", "line 1\nline 2\nline 3\nline 4\n", "add")
+	writeFile(t, "café.txt", "b\n")
+	runGit(t, "mv", "old name.txt", "naïve \"name\".txt")
+	runGit(t, "add", ".")
+	runGit(t, "commit", "-q", "-m", "change")
+
+	for _, nameStatus := range []bool{false, true} {
+		files, err := mustNew(t, WithLastCommit(true), WithDetectRenames(50), WithNameStatus(nameStatus)).ChangedFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"café.txt", "naïve \"name\".txt"}; !reflect.DeepEqual(files, want) {
+			t.Errorf("ChangedFiles() with nameStatus %v = %q, want %q", nameStatus, files, want)
+		}
+	}
+
+	g := mustNew(t, WithLastCommit(true), WithDetectRenames(50))
+	changes, err := g.ChangedFilesWithStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FileChange{
+		{Path: "café.txt", Status: "M"},
+		{Path: "naïve \"name\".txt", Status: "R", OldPath: "old name.txt"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("ChangedFilesWithStatus() = %+v, want %+v", changes, want)
+	}
+
+	stats, err := g.DiffStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Stats{FilesChanged: 2, Insertions: 1, Deletions: 1}); stats != want {
+		t.Errorf("DiffStats() = %+v, want %+v", stats, want)
+	}
+
+	writeFile(t, "café.txt", "c\n")
+	writeFile(t, "über.txt", "new\n")
+	runGit(t, "add", ".")
+	diff, err := g.DiffStagedPaths("café.txt", "über.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "+c") || !strings.Contains(diff, "+new") {
+		t.Errorf("DiffStagedPaths() =\n%s\nwant both files", diff)
+	}
+}
+
+func TestWithNameStatus(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "init")
+	writeFile(t, "a.txt", "changed\n")
+	writeFile(t, "b.txt", "b\n")
+	runGit(t, "add", ".")
+	runGit(t, "commit", "-q", "-m", "change")
+
+	tests := []struct {
+		nameStatus bool
+		want       string
+	}{
+		{nameStatus: false, want: "a.txt\x00b.txt\x00"},
+		{nameStatus: true, want: "M\x00a.txt\x00A\x00b.txt\x00"},
+	}
+	for _, tt := range tests {
+		g := mustNew(t, WithLastCommit(true), WithNameStatus(tt.nameStatus))
+		cmd, err := g.diffNames(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		output, err := g.output(cmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(output) != tt.want {
+			t.Errorf("diffNames() with nameStatus %v = %q, want %q", tt.nameStatus, output, tt.want)
+		}
+
+		files, err := g.ChangedFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"a.txt", "b.txt"}; !reflect.DeepEqual(files, want) {
+			t.Errorf("ChangedFiles() with nameStatus %v = %v, want %v", tt.nameStatus, files, want)
+		}
+	}
+}
+
+func TestWithRangeRenameDetection(t *testing.T) {
+	setupRepo(t)
+	runGit(t, "config", "diff.renames", "false")
+	content := "line 1\nline 2\nline 3\nline 4\nline 5\nline 6\nline 7\nline 8\n"
+	commitFile(t, "old.txt", content, "add")
+	tagAt(t, "v1.0.0", 1000)
+	runGit(t, "mv", "old.txt", "new.txt")
+	runGit(t, "commit", "-q", "-m", "rename")
+	commitFile(t, "other.txt", "a\n", "other")
+	tagAt(t, "v1.1.0", 2000)
+
+	diff, err := mustNew(t, WithDiffTagPrefix("v"), WithRangeRenameDetection(true)).DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "rename from old.txt\nrename to new.txt\n") {
+		t.Errorf("DiffFiles() missing rename header:\n%s", diff)
+	}
+
+	diff, err = mustNew(t, WithDiffTagPrefix("v")).DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(diff, "rename from") {
+		t.Errorf("DiffFiles() without range rename detection should not detect renames:\n%s", diff)
+	}
+}
+
+func TestWithOnlyExtensions(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "README.md", "old\n", "init")
+	writeFile(t, "main.go", "package main\n")
+	writeFile(t, "pkg/util.go", "package pkg\n")
+	writeFile(t, "README.md", "new\n")
+	writeFile(t, "config.json", "{}\n")
+	runGit(t, "add", ".")
+	runGit(t, "commit", "-q", "-m", "change")
+
+	for _, exts := range [][]string{{".go"}, {"go"}} {
+		g := mustNew(t, WithLastCommit(true), WithOnlyExtensions(exts))
+		files, err := g.ChangedFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"main.go", "pkg/util.go"}; !reflect.DeepEqual(files, want) {
+			t.Errorf("ChangedFiles() with %q = %v, want %v", exts, files, want)
+		}
+		diff, err := g.DiffFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(diff, "README.md") || strings.Contains(diff, "config.json") || !strings.Contains(diff, "pkg/util.go") {
+			t.Errorf("DiffFiles() with %q =\n%s\nwant only .go files", exts, diff)
+		}
+	}
+
+	if _, err := New(WithOnlyExtensions([]string{"."})); !errors.Is(err, errorsInvalidExtension) {
+		t.Errorf("New() with an empty extension error = %v, want %v", err, errorsInvalidExtension)
+	}
+	_, err := New(WithOnlyExtensions([]string{".go"}), WithIncludeList([]string{"pkg"}))
+	if !errors.Is(err, errorsExtensionsWithPaths) {
+		t.Errorf("New() with an include list error = %v, want %v", err, errorsExtensionsWithPaths)
+	}
+}
+
+func TestWordDiff(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "README.md", "the quick brown fox\n", "init")
+	writeFile(t, "README.md", "the quick red fox\n")
+
+	diff, err := mustNew(t, WithWordDiff(true)).DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"\n the quick \n", "\n-brown\n", "\n+red\n", "\n~\n"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("DiffFiles() missing %q:\n%s", want, diff)
+		}
+	}
+	if strings.Contains(diff, "-the quick brown fox") {
+		t.Errorf("DiffFiles() should not replace the full line:\n%s", diff)
+	}
+}
+
+func TestFunctionContext(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "main.go", "package main\n\nfunc main() {\n\ta := 1\n\tb := 2\n\tc := 3\n\td := 4\n\tprintln(a, b, c, d)\n}\n", "init")
+	writeFile(t, "main.go", "package main\n\nfunc main() {\n\ta := 1\n\tb := 2\n\tc := 30\n\td := 4\n\tprintln(a, b, c, d)\n}\n")
+
+	g := mustNew(t, WithFunctionContext(true))
+	cmd, err := g.diffFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !containsArg(cmd.Args, "--function-context") || !containsArg(cmd.Args, "--unified=0") {
+		t.Errorf("args = %v, want --function-context alongside --unified=0", cmd.Args)
+	}
+
+	diff, err := g.DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"\n func main() {\n", "\n \ta := 1\n", "\n+\tc := 30\n", "\n \tprintln(a, b, c, d)\n", "\n }\n"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("DiffFiles() missing %q:\n%s", want, diff)
+		}
+	}
+}
+
+func TestSubmodules(t *testing.T) {
+	setupRepo(t)
+	upstream := t.TempDir()
+	runGit(t, "-C", upstream, "init", "-q", "-b", "main")
+	runGit(t, "-C", upstream, "-c", "user.name=tester", "-c", "user.email=tester@example.com",
+		"commit", "-q", "--allow-empty", "-m", "initial")
+	runGit(t, "-c", "protocol.file.allow=always", "submodule", "add", "-q", upstream, "sub")
+	runGit(t, "commit", "-q", "-m", "add submodule")
+
+	writeFile(t, "sub/lib.txt", "lib\n")
+	runGit(t, "-C", "sub", "add", "lib.txt")
+	runGit(t, "-C", "sub", "-c", "user.name=tester", "-c", "user.email=tester@example.com",
+		"commit", "-q", "-m", "add lib")
+
+	tests := []struct {
+		name string
+		mode string
+		want string
+	}{
+		{name: "default", mode: "", want: "+Subproject commit "},
+		{name: "short", mode: "short", want: "+Subproject commit "},
+		{name: "log", mode: "log", want: "  > add lib"},
+		{name: "diff", mode: "diff", want: "+++ b/sub/lib.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := mustNew(t, WithSubmodules(tt.mode)).DiffFiles()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(diff, tt.want) {
+				t.Errorf("DiffFiles() missing %q:\n%s", tt.want, diff)
+			}
+		})
+	}
+
+	if _, err := New(WithSubmodules("full")); !errors.Is(err, errorsInvalidSubmoduleMode) {
+		t.Errorf("New() error = %v, want %v", err, errorsInvalidSubmoduleMode)
+	}
+}
+
+func TestDiffAlgorithm(t *testing.T) {
+	cmd, err := mustNew(t, WithDiffAlgorithm("histogram")).diffFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(strings.Join(cmd.Args, " "), "--diff-algorithm=histogram") {
+		t.Errorf("diffFiles() args = %v, want --diff-algorithm=histogram", cmd.Args)
+	}
+
+	cmd, err = mustNew(t).diffFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(strings.Join(cmd.Args, " "), "--diff-algorithm=minimal") {
+		t.Errorf("diffFiles() args = %v, want --diff-algorithm=minimal", cmd.Args)
+	}
+
+	if _, err := New(WithDiffAlgorithm("fastest")); !errors.Is(err, errorsInvalidDiffAlgorithm) {
+		t.Errorf("New() error = %v, want %v", err, errorsInvalidDiffAlgorithm)
+	}
+}
+
+func TestWhitespaceMode(t *testing.T) {
+	flags := []string{"--ignore-all-space", "--ignore-space-change", "--ignore-space-at-eol"}
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{mode: "", want: "--ignore-all-space"},
+		{mode: "all", want: "--ignore-all-space"},
+		{mode: "change", want: "--ignore-space-change"},
+		{mode: "eol", want: "--ignore-space-at-eol"},
+		{mode: "none", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			cmd, err := mustNew(t, WithWhitespaceMode(tt.mode)).diffFiles(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, flag := range flags {
+				has := strings.Contains(strings.Join(cmd.Args, " "), flag)
+				if has != (flag == tt.want) {
+					t.Errorf("diffFiles() args = %v, want whitespace flag %q", cmd.Args, tt.want)
+				}
+			}
+		})
+	}
+
+	if _, err := New(WithWhitespaceMode("tabs")); !errors.Is(err, errorsInvalidWhitespaceMode) {
+		t.Errorf("New() error = %v, want %v", err, errorsInvalidWhitespaceMode)
+	}
+}
+
+func TestDiffFilesStderr(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+
+	_, err := mustNew(t, WithCommitRange("nosuchrev", "HEAD")).DiffFiles()
+	if err == nil {
+		t.Fatal("DiffFiles() error = nil, want error")
+	}
+	if !strings.Contains(err.Error(), "nosuchrev") {
+		t.Errorf("DiffFiles() error = %v, want git stderr mentioning nosuchrev", err)
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	setupRepo(t)
+	for _, name := range []string{"a.go", "b.go", "go.sum"} {
+		writeFile(t, name, "one\n")
+	}
+	runGit(t, "add", ".")
+	runGit(t, "commit", "-q", "-m", "init")
+	for _, name := range []string{"a.go", "b.go", "go.sum"} {
+		writeFile(t, name, "two\n")
+	}
+
+	got, err := mustNew(t).ChangedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.go", "b.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ChangedFiles() = %v, want %v", got, want)
+	}
+
+	runGit(t, "checkout", "--", ".")
+	got, err = mustNew(t).ChangedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("ChangedFiles() = %v, want none", got)
+	}
+}
+
+// fakeGit writes an executable shell script wrapping git and returns its path.
+// The script body runs before git is executed with the original arguments.
+func fakeGit(t *testing.T, body string) string {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script wrappers are not supported on windows")
+	}
+
+	realGit, err := exec.LookPath("git")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	script := filepath.Join(t.TempDir(), "git")
+	content := "#!/bin/sh\n" + body + "\nexec " + realGit + " \"$@\"\n"
+	if err := os.WriteFile(script, []byte(content), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return script
+}
+
+func TestErrGitNotFound(t *testing.T) {
+	setupRepo(t)
+	missing := filepath.Join(t.TempDir(), "no-such-git")
+
+	if _, err := New(WithGitBinary(missing)); !errors.Is(err, ErrGitNotFound) || !strings.Contains(err.Error(), missing) {
+		t.Errorf("New() error = %v, want %v naming %s", err, ErrGitNotFound, missing)
+	}
+
+	g := mustNew(t, WithGitBinary(missing), WithBackend(BackendGoGit))
+	if err := g.CheckGit(); !errors.Is(err, ErrGitNotFound) {
+		t.Errorf("CheckGit() error = %v, want %v", err, ErrGitNotFound)
+	}
+	if err := mustNew(t).CheckGit(); err != nil {
+		t.Errorf("CheckGit() error = %v, want none", err)
+	}
+}
+
+func TestGitBinaryAndEnv(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "a.txt", "b\n")
+
+	log := filepath.Join(t.TempDir(), "git.log")
+	binary := fakeGit(t, `echo "$ZCODE_TEST $1" >> `+log)
+
+	g := mustNew(t,
+		WithGitBinary(binary),
+		WithEnv([]string{"ZCODE_TEST=wrapped"}),
+	)
+	if _, err := g.DiffFiles(); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(content), "wrapped diff"); got != 2 {
+		t.Errorf("wrapper invocations = %d, want 2:\n%s", got, content)
+	}
+}
+
+func TestWorkingDir(t *testing.T) {
+	dir := setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "a.txt", "b\n")
+
+	outside := t.TempDir()
+	if err := os.Chdir(outside); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := mustNew(t, WithWorkingDir(dir)).DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "+b") {
+		t.Errorf("DiffFiles() = %q, want change of a.txt", diff)
+	}
+
+	for _, invalid := range []string{filepath.Join(outside, "missing"), outside} {
+		if _, err := New(WithWorkingDir(invalid)); err == nil {
+			t.Errorf("New(WithWorkingDir(%q)) error = nil, want error", invalid)
+		}
+	}
+}
+
+func TestHasStagedChanges(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	g := mustNew(t)
+
+	writeFile(t, "a.txt", "unstaged\n")
+	if got, err := g.HasStagedChanges(); err != nil || got {
+		t.Errorf("HasStagedChanges() = %v, %v, want false for a clean index", got, err)
+	}
+
+	runGit(t, "add", "a.txt")
+	if got, err := g.HasStagedChanges(); err != nil || !got {
+		t.Errorf("HasStagedChanges() = %v, %v, want true for a dirty index", got, err)
+	}
+
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.HasStagedChanges(); err == nil {
+		t.Error("HasStagedChanges() outside a repository error = nil, want error")
+	}
+}
@@ -0,0 +1,374 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/diff"
+	dmp "github.com/sergi/go-diff/diffmatchpatch"
+)
+
+var errorsUnsupportedByGoGit = errors.New("option is not supported by the go-git backend")
+
+// goGitDiffer computes diffs in pure Go with go-git, without running the git binary.
+type goGitDiffer struct {
+	c *Command
+}
+
+func (d goGitDiffer) changedFiles(ctx context.Context) ([]string, error) {
+	if err := d.unsupported(false); err != nil {
+		return nil, err
+	}
+	repo, err := d.open()
+	if err != nil {
+		return nil, err
+	}
+
+	from, to, err := d.revisions()
+	if err != nil {
+		return nil, err
+	}
+	if to == "" {
+		return d.worktreeFiles(repo)
+	}
+
+	changes, err := d.changes(ctx, repo, from, to)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(changes))
+	for _, ch := range changes {
+		names = append(names, changeName(ch))
+	}
+	return names, nil
+}
+
+func (d goGitDiffer) diff(ctx context.Context) (string, error) {
+	if err := d.unsupported(true); err != nil {
+		return "", err
+	}
+	repo, err := d.open()
+	if err != nil {
+		return "", err
+	}
+
+	from, to, err := d.revisions()
+	if err != nil {
+		return "", err
+	}
+
+	var patch fdiff.Patch
+	if to == "" {
+		patch, err = d.worktreePatch(repo)
+	} else {
+		var changes object.Changes
+		changes, err = d.changes(ctx, repo, from, to)
+		if err == nil {
+			patch, err = changes.PatchContext(ctx)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := fdiff.NewUnifiedEncoder(&buf, d.c.diffUnified).Encode(patch); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (d goGitDiffer) open() (*gogit.Repository, error) {
+	dir := d.c.workingDir
+	if dir == "" {
+		dir = "."
+	}
+	return gogit.PlainOpenWithOptions(dir, &gogit.PlainOpenOptions{DetectDotGit: true})
+}
+
+// unsupported returns an error naming the first configured option the go-git backend cannot honor,
+// so it never returns a different diff than the exec backend would. Options that only shape
+// the patch text are checked when patch is set.
+func (d goGitDiffer) unsupported(patch bool) error {
+	c := d.c
+	options := []struct {
+		set  bool
+		name string
+	}{
+		{c.diffFilter != "", "WithDiffFilter"},
+		{c.renameThreshold > 0 || c.rangeRenames, "WithDetectRenames"},
+		{c.nameStatus, "WithNameStatus"},
+		{c.excludeGenerated, "WithExcludeGenerated"},
+		{c.includeUntracked, "WithIncludeUntracked"},
+	}
+	if patch {
+		options = append(options, []struct {
+			set  bool
+			name string
+		}{
+			{c.whitespaceMode != "none", `WithWhitespaceMode other than "none"`},
+			{c.diffAlgorithm != defaultDiffAlgorithm, "WithDiffAlgorithm"},
+			{c.wordDiff, "WithWordDiff"},
+			{c.functionContext, "WithFunctionContext"},
+			{c.textConv, "WithTextConv"},
+			{c.color, "WithColor"},
+			{c.omitBinary, "WithOmitBinary"},
+			{len(c.extraDiffArgs) > 0, "WithExtraDiffArgs"},
+		}...)
+	}
+
+	for _, o := range options {
+		if o.set {
+			return fmt.Errorf("%w: %s", errorsUnsupportedByGoGit, o.name)
+		}
+	}
+	return nil
+}
+
+// revisions returns the revisions compared by the active range,
+// or empty strings to compare the working tree against the index.
+func (d goGitDiffer) revisions() (from, to string, err error) {
+	c := d.c
+	switch {
+	case c.commitFrom != "":
+		to = c.commitTo
+		if to == "" {
+			to = "HEAD"
+		}
+		return c.commitFrom, to, nil
+	case c.baseBranch != "" && c.headBranch != "":
+		return "", "", fmt.Errorf("%w: WithBranches", errorsUnsupportedByGoGit)
+	case c.upstream:
+		return "", "", fmt.Errorf("%w: WithUpstream", errorsUnsupportedByGoGit)
+	case c.stashRef != "":
+		return "", "", fmt.Errorf("%w: WithStash", errorsUnsupportedByGoGit)
+	case c.tagToHead != "":
+		return "refs/tags/" + c.tagToHead, "HEAD", nil
+	case c.diffTagPrefix != "" || c.tagPattern != "":
+		return "", "", fmt.Errorf("%w: WithDiffTagPrefix and WithTagPattern", errorsUnsupportedByGoGit)
+	case c.mergeCommit != "":
+		return "", "", fmt.Errorf("%w: WithMergeCommit", errorsUnsupportedByGoGit)
+	case c.commitId != "":
+		return c.commitId + "^", c.commitId, nil
+	case c.isAmend || c.lastCommit:
+		return "HEAD^", "HEAD", nil
+	}
+	return "", "", nil
+}
+
+// tree returns the tree of the commit rev resolves to.
+// A trailing "^" selects the first parent, which is the empty (nil) tree for a root commit.
+func (d goGitDiffer) tree(repo *gogit.Repository, rev string) (*object.Tree, error) {
+	base := strings.TrimSuffix(rev, "^")
+	hash, err := repo.ResolveRevision(plumbing.Revision(base))
+	if err != nil {
+		return nil, fmt.Errorf("ref %s not found: %w", base, err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if base != rev {
+		if commit.NumParents() == 0 {
+			return nil, nil
+		}
+		if commit, err = commit.Parent(0); err != nil {
+			return nil, err
+		}
+	}
+	return commit.Tree()
+}
+
+// changes returns the changes between two revisions selected by the include and exclude lists, sorted by path.
+func (d goGitDiffer) changes(ctx context.Context, repo *gogit.Repository, from, to string) (object.Changes, error) {
+	a, err := d.tree(repo, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := d.tree(repo, to)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := object.DiffTreeWithOptions(ctx, a, b, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected object.Changes
+	for _, ch := range changes {
+		if d.c.selectsPath(changeName(ch)) {
+			selected = append(selected, ch)
+		}
+	}
+	sort.Sort(selected)
+	return selected, nil
+}
+
+// changeName returns the path of a tree change, which is the old path for deletions.
+func changeName(ch *object.Change) string {
+	if ch.To.Name != "" {
+		return ch.To.Name
+	}
+	return ch.From.Name
+}
+
+// worktreeFiles returns the tracked files modified or deleted in the working tree, sorted by path.
+func (d goGitDiffer) worktreeFiles(repo *gogit.Repository) ([]string, error) {
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for path, st := range status {
+		if st.Worktree != gogit.Modified && st.Worktree != gogit.Deleted {
+			continue
+		}
+		if d.c.selectsPath(path) {
+			names = append(names, path)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// worktreePatch returns the patch from the index to the working tree.
+func (d goGitDiffer) worktreePatch(repo *gogit.Repository) (fdiff.Patch, error) {
+	names, err := d.worktreeFiles(repo)
+	if err != nil {
+		return nil, err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+
+	var patch goGitPatch
+	for _, name := range names {
+		entry, err := idx.Entry(name)
+		if err != nil {
+			return nil, err
+		}
+		blob, err := repo.BlobObject(entry.Hash)
+		if err != nil {
+			return nil, err
+		}
+		reader, err := blob.Reader()
+		if err != nil {
+			return nil, err
+		}
+		fromContent, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		fp := &goGitFilePatch{
+			from: goGitFile{hash: entry.Hash, mode: entry.Mode, path: name},
+		}
+
+		toContent, err := os.ReadFile(filepath.Join(wt.Filesystem.Root(), name))
+		switch {
+		case os.IsNotExist(err):
+			toContent = nil
+		case err != nil:
+			return nil, err
+		default:
+			fp.to = goGitFile{
+				hash: plumbing.ComputeHash(plumbing.BlobObject, toContent),
+				mode: entry.Mode,
+				path: name,
+			}
+		}
+
+		fp.binary = isBinary(fromContent) || isBinary(toContent)
+		if !fp.binary {
+			fp.chunks = textChunks(string(fromContent), string(toContent))
+		}
+		patch = append(patch, fp)
+	}
+	return patch, nil
+}
+
+// isBinary reports whether content looks binary, the way git does: it has a NUL byte in its first 8000 bytes.
+func isBinary(content []byte) bool {
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+	return bytes.IndexByte(content, 0) != -1
+}
+
+// textChunks computes the line changes turning from into to.
+func textChunks(from, to string) []fdiff.Chunk {
+	var chunks []fdiff.Chunk
+	for _, d := range diff.Do(from, to) {
+		op := fdiff.Equal
+		switch d.Type {
+		case dmp.DiffDelete:
+			op = fdiff.Delete
+		case dmp.DiffInsert:
+			op = fdiff.Add
+		}
+		chunks = append(chunks, goGitChunk{content: d.Text, op: op})
+	}
+	return chunks
+}
+
+// goGitPatch implements fdiff.Patch for working tree changes.
+type goGitPatch []fdiff.FilePatch
+
+func (p goGitPatch) FilePatches() []fdiff.FilePatch { return p }
+func (p goGitPatch) Message() string                { return "" }
+
+// goGitFilePatch implements fdiff.FilePatch. A nil from or to marks an added or deleted file.
+type goGitFilePatch struct {
+	from, to fdiff.File
+	chunks   []fdiff.Chunk
+	binary   bool
+}
+
+func (p *goGitFilePatch) IsBinary() bool               { return p.binary }
+func (p *goGitFilePatch) Files() (from, to fdiff.File) { return p.from, p.to }
+func (p *goGitFilePatch) Chunks() []fdiff.Chunk        { return p.chunks }
+
+// goGitFile implements fdiff.File.
+type goGitFile struct {
+	hash plumbing.Hash
+	mode filemode.FileMode
+	path string
+}
+
+func (f goGitFile) Hash() plumbing.Hash     { return f.hash }
+func (f goGitFile) Mode() filemode.FileMode { return f.mode }
+func (f goGitFile) Path() string            { return f.path }
+
+// goGitChunk implements fdiff.Chunk.
+type goGitChunk struct {
+	content string
+	op      fdiff.Operation
+}
+
+func (c goGitChunk) Content() string       { return c.content }
+func (c goGitChunk) Type() fdiff.Operation { return c.op }
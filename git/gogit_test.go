@@ -0,0 +1,169 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// backendFiles returns the changed files reported by each backend for the same options.
+func backendFiles(t *testing.T, opts ...Option) (execFiles, goGitFiles []string) {
+	t.Helper()
+
+	var err error
+	execFiles, err = mustNew(t, append(opts, WithBackend(BackendExec))...).ChangedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	goGitFiles, err = mustNew(t, append(opts, WithBackend(BackendGoGit))...).ChangedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return execFiles, goGitFiles
+}
+
+func TestBackendWorktree(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.go", "package a\n", "add a")
+	commitFile(t, "b.go", "package b\n", "add b")
+	commitFile(t, "go.sum", "sum\n", "add go.sum")
+	commitFile(t, "docs/readme.md", "docs\n", "add docs")
+
+	writeFile(t, "a.go", "package a\n\nfunc A() {}\n")
+	writeFile(t, "go.sum", "changed\n")
+	writeFile(t, "docs/readme.md", "more docs\n")
+	if err := os.Remove("b.go"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, "untracked.go", "package untracked\n")
+
+	execFiles, goGitFiles := backendFiles(t)
+	want := []string{"a.go", "b.go", "docs/readme.md"}
+	if !reflect.DeepEqual(execFiles, want) {
+		t.Errorf("exec backend: got %v, want %v", execFiles, want)
+	}
+	if !reflect.DeepEqual(goGitFiles, execFiles) {
+		t.Errorf("go-git backend: got %v, want %v", goGitFiles, execFiles)
+	}
+
+	execFiles, goGitFiles = backendFiles(t, WithIncludeList([]string{"docs"}))
+	if !reflect.DeepEqual(goGitFiles, execFiles) {
+		t.Errorf("include list: go-git got %v, exec got %v", goGitFiles, execFiles)
+	}
+
+	out, err := mustNew(t, WithBackend(BackendGoGit), WithWhitespaceMode("none")).DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"diff --git a/a.go b/a.go", "+func A() {}", "deleted file mode", "-package b"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("go-git diff is missing %q:\n%s", s, out)
+		}
+	}
+}
+
+func TestBackendCommits(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.go", "package a\n", "add a")
+	from := runGit(t, "rev-parse", "HEAD")
+	runGit(t, "tag", "-a", "-m", "v1.0.0", "v1.0.0")
+	commitFile(t, "b.go", "package b\n", "add b")
+	commitFile(t, "a.go", "package a\n\nfunc A() {}\n", "update a")
+	runGit(t, "rm", "-q", "b.go")
+	runGit(t, "commit", "-q", "-m", "remove b")
+	root := runGit(t, "rev-list", "--max-parents=0", "HEAD")
+
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "commit range", opts: []Option{WithCommitRange(from, "HEAD~1")}},
+		{name: "commit range to HEAD", opts: []Option{WithCommitRange(from, "")}},
+		{name: "tag to HEAD", opts: []Option{WithTagToHead("v1.0.0")}},
+		{name: "commit id", opts: []Option{WithCommitId("HEAD~1")}},
+		{name: "root commit", opts: []Option{WithCommitId(root)}},
+		{name: "amend", opts: []Option{WithEnableAmend(true)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			execFiles, goGitFiles := backendFiles(t, tt.opts...)
+			if len(execFiles) == 0 {
+				t.Fatal("exec backend reported no changes")
+			}
+			if !reflect.DeepEqual(goGitFiles, execFiles) {
+				t.Errorf("go-git got %v, exec got %v", goGitFiles, execFiles)
+			}
+		})
+	}
+}
+
+func TestBackendUnsupported(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.go", "package a\n", "add a")
+	runGit(t, "tag", "v1.0.0")
+
+	g := mustNew(t, WithBackend(BackendGoGit), WithDiffTagPrefix("v"))
+	if _, err := g.ChangedFiles(); !errors.Is(err, errorsUnsupportedByGoGit) {
+		t.Errorf("got %v, want %v", err, errorsUnsupportedByGoGit)
+	}
+	if _, err := g.DiffFilesContext(context.Background()); !errors.Is(err, errorsUnsupportedByGoGit) {
+		t.Errorf("got %v, want %v", err, errorsUnsupportedByGoGit)
+	}
+}
+
+func TestBackendUnsupportedDiffOptions(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.go", "package a\n", "add a")
+	writeFile(t, "a.go", "package a\n\nfunc A() {}\n")
+
+	if _, err := mustNew(t, WithBackend(BackendGoGit)).DiffFiles(); !errors.Is(err, errorsUnsupportedByGoGit) {
+		t.Errorf("default whitespace mode: got %v, want %v", err, errorsUnsupportedByGoGit)
+	}
+
+	tests := map[string]Option{
+		"renames":          WithDetectRenames(50),
+		"name status":      WithNameStatus(true),
+		"word diff":        WithWordDiff(true),
+		"diff algorithm":   WithDiffAlgorithm("histogram"),
+		"function context": WithFunctionContext(true),
+	}
+	for name, opt := range tests {
+		g := mustNew(t, WithBackend(BackendGoGit), WithWhitespaceMode("none"), opt)
+		if _, err := g.DiffFiles(); !errors.Is(err, errorsUnsupportedByGoGit) {
+			t.Errorf("%s: got %v, want %v", name, err, errorsUnsupportedByGoGit)
+		}
+	}
+}
+
+func TestBackendWithoutGitBinary(t *testing.T) {
+	dir := setupRepo(t)
+	commitFile(t, "a.go", "package a\n", "add a")
+	writeFile(t, "a.go", "package a\n\nfunc A() {}\n")
+	missing := filepath.Join(t.TempDir(), "no-such-git")
+
+	g, err := New(WithBackend(BackendGoGit), WithGitBinary(missing), WithWorkingDir(dir), WithWhitespaceMode("none"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := g.ChangedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.go"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("ChangedFiles() = %v, want %v", files, want)
+	}
+
+	if _, err := New(WithBackend(BackendGoGit), WithGitBinary(missing), WithWorkingDir(t.TempDir())); err == nil {
+		t.Error("New() outside a work tree error = nil, want an error")
+	}
+}
+
+func TestWithBackend(t *testing.T) {
+	if _, err := New(WithBackend("svn")); !errors.Is(err, errorsInvalidBackend) {
+		t.Errorf("got %v, want %v", err, errorsInvalidBackend)
+	}
+}
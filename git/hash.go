@@ -0,0 +1,29 @@
+package git
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// DiffHash returns a hex SHA-256 of the diff returned by DiffFiles together with the compared range,
+// so tools can use it as a cache key: identical diffs of the same range always hash the same.
+// Line endings are normalized to LF before hashing.
+func (c *Command) DiffHash() (string, error) {
+	ctx := context.Background()
+	diff, err := c.DiffFilesContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	subcommand, revs, err := c.diffRange(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write([]byte(strings.Join(append(subcommand, revs...), " ")))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.ReplaceAll(diff, "\r\n", "\n")))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,28 @@
+package git
+
+import (
+	"testing"
+)
+
+func TestDiffHash(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "a.txt", "b\n")
+	g := mustNew(t)
+
+	first, err := g.DiffHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 64 {
+		t.Errorf("DiffHash() = %q, want 64 hex digits", first)
+	}
+	if second, err := mustNew(t).DiffHash(); err != nil || second != first {
+		t.Errorf("DiffHash() = %q, %v, want %q on an unchanged repository", second, err, first)
+	}
+
+	writeFile(t, "a.txt", "c\n")
+	if changed, err := g.DiffHash(); err != nil || changed == first {
+		t.Errorf("DiffHash() = %q, %v, want a different hash after a change", changed, err)
+	}
+}
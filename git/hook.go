@@ -1,9 +1,17 @@
 package git
 
 import (
+	"bytes"
 	"embed"
+	"errors"
+	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 
+	"github.com/appleboy/com/file"
 	"github.com/carsonfeng/ZCode/util"
 )
 
@@ -12,11 +20,233 @@ var files embed.FS
 
 const (
 	HookPrepareCommitMessageTemplate = "prepare-commit-msg"
+	HookCommitMessageTemplate        = "commit-msg"
+	HookPreCommitTemplate            = "pre-commit"
 	CommitMessageTemplate            = "commit-msg.tmpl"
 )
 
+var errorsInvalidHookPattern = errors.New("invalid commit message pattern")
+
+// hookMarker is the comment that identifies hook files installed by zcode.
+const hookMarker = "# zcode: managed hook"
+
 func init() {
 	if err := util.LoadTemplates(files); err != nil {
 		log.Fatal(err)
 	}
 }
+
+// HookKind is the name of a git hook that zcode can install.
+type HookKind string
+
+const (
+	HookPrepareCommitMsg HookKind = "prepare-commit-msg"
+	HookCommitMsg        HookKind = "commit-msg"
+	HookPreCommit        HookKind = "pre-commit"
+)
+
+// hookTemplates maps each hook kind to the name of the template it is rendered from.
+var hookTemplates = map[HookKind]string{
+	HookPrepareCommitMsg: HookPrepareCommitMessageTemplate,
+	HookCommitMsg:        HookCommitMessageTemplate,
+	HookPreCommit:        HookPreCommitTemplate,
+}
+
+// conventionalPattern is the extended regular expression the commit-msg hook checks subjects against by default.
+var conventionalPattern = `^(` + strings.Join(ConventionalTypes, "|") + `)(\([^)]+\))?!?: .+`
+
+// hookData returns the template data used to render the hook of the given kind.
+func hookData(kind HookKind) util.Data {
+	if kind == HookCommitMsg {
+		return commitMsgHookData(conventionalPattern)
+	}
+	return nil
+}
+
+// commitMsgHookData returns the template data of a commit-msg hook checking subjects against pattern.
+func commitMsgHookData(pattern string) util.Data {
+	return util.Data{"pattern": quoteArg(pattern)}
+}
+
+// InstallHook installs the prepare-commit-msg hook.
+func (c *Command) InstallHook() error {
+	return c.InstallHookType(HookPrepareCommitMsg)
+}
+
+// UninstallHook removes the prepare-commit-msg hook.
+func (c *Command) UninstallHook() error {
+	return c.UninstallHookType(HookPrepareCommitMsg)
+}
+
+// InstallHookType installs the hook of the given kind into the hooks directory,
+// rendered from the template registered for that kind.
+func (c *Command) InstallHookType(kind HookKind) error {
+	return c.installHook(kind, hookData(kind))
+}
+
+// InstallCommitMsgValidator installs a commit-msg hook rejecting commits, generated or not,
+// whose subject line does not match pattern, an extended regular expression as understood by grep -E.
+// An empty pattern checks for a conventional commit subject, like InstallHookType(HookCommitMsg).
+func (c *Command) InstallCommitMsgValidator(pattern string) error {
+	if pattern == "" {
+		pattern = conventionalPattern
+	}
+	// grep -E and Go share the common regular expression syntax, which catches most mistakes early.
+	if _, err := regexp.Compile(pattern); err != nil {
+		return fmt.Errorf("%w: %v", errorsInvalidHookPattern, err)
+	}
+	return c.installHook(HookCommitMsg, commitMsgHookData(pattern))
+}
+
+// installHook installs the hook of the given kind, rendered with data.
+func (c *Command) installHook(kind HookKind, data util.Data) error {
+	target, err := c.hookTarget(kind)
+	if err != nil {
+		return err
+	}
+
+	if file.IsFile(target) {
+		if !c.forceHook {
+			return fmt.Errorf("hook file %s exist.", kind)
+		}
+		if err := checkOwnHook(target); err != nil {
+			return err
+		}
+	}
+
+	content, err := renderHook(kind, data)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(target, []byte(content), 0o755)
+}
+
+// InstallHookAppend installs the prepare-commit-msg hook like InstallHook, but when a hook already exists,
+// appends our hook body to it between BEGIN/END markers instead of failing.
+// Appending again replaces the previously appended block.
+func (c *Command) InstallHookAppend() error {
+	kind := HookPrepareCommitMsg
+	target, err := c.hookTarget(kind)
+	if err != nil {
+		return err
+	}
+
+	if !file.IsFile(target) {
+		return c.InstallHookType(kind)
+	}
+
+	existing, err := os.ReadFile(target)
+	if err != nil {
+		return err
+	}
+	script, _ := stripHookBlock(string(existing))
+	if strings.Contains(script, hookMarker) {
+		// the whole hook is already ours
+		return nil
+	}
+
+	content, err := renderHook(kind, hookData(kind))
+	if err != nil {
+		return err
+	}
+
+	if script != "" && !strings.HasSuffix(script, "\n") {
+		script += "\n"
+	}
+	return os.WriteFile(target, []byte(script+hookBlock(content)), 0o755)
+}
+
+// UninstallHookType removes the hook of the given kind from the hooks directory.
+// When our hook was appended to an existing hook, only the appended block is removed.
+func (c *Command) UninstallHookType(kind HookKind) error {
+	target, err := c.hookTarget(kind)
+	if err != nil {
+		return err
+	}
+
+	if !file.IsFile(target) {
+		return fmt.Errorf("hook file %s is not exist.", kind)
+	}
+
+	content, err := os.ReadFile(target)
+	if err != nil {
+		return err
+	}
+	if script, ok := stripHookBlock(string(content)); ok {
+		return os.WriteFile(target, []byte(script), 0o755)
+	}
+	return os.Remove(target)
+}
+
+// hookTarget returns the path of the hook file of the given kind.
+func (c *Command) hookTarget(kind HookKind) (string, error) {
+	if _, ok := hookTemplates[kind]; !ok {
+		return "", fmt.Errorf("unsupported hook kind: %s", kind)
+	}
+
+	hooksDir, err := c.HooksDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(hooksDir, string(kind)), nil
+}
+
+// renderHook renders the hook script of the given kind with data.
+func renderHook(kind HookKind, data util.Data) (string, error) {
+	return util.GetTemplateByString(hookTemplates[kind], data)
+}
+
+const (
+	hookBlockBegin = "# BEGIN zcode block"
+	hookBlockEnd   = "# END zcode block"
+)
+
+// hookBlock wraps the body of a rendered hook script between BEGIN/END markers,
+// dropping the shebang and managed marker lines so the block can be appended to another script.
+func hookBlock(script string) string {
+	var b strings.Builder
+	b.WriteString(hookBlockBegin + "\n")
+	for _, line := range strings.Split(strings.TrimRight(script, "\n"), "\n") {
+		if strings.HasPrefix(line, "#!") || line == hookMarker {
+			continue
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString(hookBlockEnd + "\n")
+	return b.String()
+}
+
+// stripHookBlock removes the BEGIN/END marked block from script, reporting whether one was found.
+func stripHookBlock(script string) (string, bool) {
+	start := strings.Index(script, hookBlockBegin+"\n")
+	if start == -1 {
+		return script, false
+	}
+	end := strings.Index(script[start:], hookBlockEnd+"\n")
+	if end == -1 {
+		return script, false
+	}
+	end += start + len(hookBlockEnd) + 1
+
+	return script[:start] + script[end:], true
+}
+
+// checkOwnHook returns an error unless the existing hook file was installed by zcode and is writable,
+// so a user-authored hook is never overwritten.
+func checkOwnHook(target string) error {
+	content, err := os.ReadFile(target)
+	if err != nil {
+		return err
+	}
+	if !bytes.Contains(content, []byte(hookMarker)) {
+		return fmt.Errorf("hook file %s was not installed by zcode, refusing to overwrite it", filepath.Base(target))
+	}
+
+	f, err := os.OpenFile(target, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("hook file %s is not writable: %w", filepath.Base(target), err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,269 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// samePath reports whether a and b refer to the same path once symlinks are resolved.
+func samePath(t *testing.T, a, b string) bool {
+	t.Helper()
+
+	ra, err := filepath.EvalSymlinks(a)
+	if err != nil {
+		ra = a
+	}
+	rb, err := filepath.EvalSymlinks(b)
+	if err != nil {
+		rb = b
+	}
+	return ra == rb
+}
+
+func TestGitDirAndHooksDir(t *testing.T) {
+	dir := setupRepo(t)
+	g := mustNew(t)
+
+	gitDir, err := g.GitDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.HasSuffix(gitDir, "\n") {
+		t.Errorf("GitDir() = %q, want no trailing newline", gitDir)
+	}
+	if !samePath(t, gitDir, ".git") {
+		t.Errorf("GitDir() = %q, want .git", gitDir)
+	}
+
+	hooksDir, err := g.HooksDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.HasSuffix(hooksDir, "\n") || !filepath.IsAbs(hooksDir) {
+		t.Errorf("HooksDir() = %q, want an absolute path without trailing newline", hooksDir)
+	}
+	if !samePath(t, hooksDir, filepath.Join(dir, ".git", "hooks")) {
+		t.Errorf("HooksDir() = %q, want %q", hooksDir, filepath.Join(dir, ".git", "hooks"))
+	}
+
+	runGit(t, "config", "core.hooksPath", filepath.Join(dir, "custom-hooks"))
+	if err := os.Mkdir(filepath.Join(dir, "custom-hooks"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	hooksDir, err = g.HooksDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !samePath(t, hooksDir, filepath.Join(dir, "custom-hooks")) {
+		t.Errorf("HooksDir() = %q, want %q", hooksDir, filepath.Join(dir, "custom-hooks"))
+	}
+}
+
+func TestHooksDirWorktree(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	runGit(t, "config", "core.hooksPath", "shared-hooks")
+
+	worktree := filepath.Join(t.TempDir(), "wt")
+	runGit(t, "worktree", "add", "-q", worktree)
+
+	hooksDir, err := mustNew(t, WithWorkingDir(worktree)).HooksDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !samePath(t, hooksDir, filepath.Join(worktree, "shared-hooks")) {
+		t.Errorf("HooksDir() = %q, want %q", hooksDir, filepath.Join(worktree, "shared-hooks"))
+	}
+}
+
+func TestInstallHookForce(t *testing.T) {
+	dir := setupRepo(t)
+	target := filepath.Join(dir, ".git", "hooks", HookPrepareCommitMessageTemplate)
+
+	// fresh install
+	if err := mustNew(t).InstallHook(); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(target); err != nil || info.Mode().Perm()&0o100 == 0 {
+		t.Fatalf("hook not installed as executable: %v", err)
+	}
+
+	// re-install ours
+	if err := mustNew(t).InstallHook(); err == nil {
+		t.Error("InstallHook() without force error = nil, want error")
+	}
+	if err := mustNew(t, WithForceHook(true)).InstallHook(); err != nil {
+		t.Errorf("InstallHook() with force error = %v, want nil", err)
+	}
+
+	// refuse to clobber foreign
+	foreign := "#!/bin/sh\necho mine\n"
+	writeFile(t, target, foreign)
+	if err := mustNew(t, WithForceHook(true)).InstallHook(); err == nil {
+		t.Error("InstallHook() over foreign hook error = nil, want error")
+	}
+	if content, _ := os.ReadFile(target); string(content) != foreign {
+		t.Errorf("foreign hook was modified: %q", content)
+	}
+}
+
+func TestInstallHookType(t *testing.T) {
+	dir := setupRepo(t)
+	hooksDir := filepath.Join(dir, "custom-hooks")
+	if err := os.Mkdir(hooksDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, "config", "core.hooksPath", hooksDir)
+
+	g := mustNew(t)
+	for _, kind := range []HookKind{HookPrepareCommitMsg, HookCommitMsg, HookPreCommit} {
+		t.Run(string(kind), func(t *testing.T) {
+			if err := g.InstallHookType(kind); err != nil {
+				t.Fatal(err)
+			}
+
+			target := filepath.Join(hooksDir, string(kind))
+			info, err := os.Stat(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm()&0o111 == 0 {
+				t.Errorf("hook %s mode = %v, want executable", kind, info.Mode())
+			}
+			content, err := os.ReadFile(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(string(content), "#!/bin/sh\n"+hookMarker) {
+				t.Errorf("hook %s content = %q, want a managed shell script", kind, content)
+			}
+
+			if err := g.UninstallHookType(kind); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(target); !os.IsNotExist(err) {
+				t.Errorf("hook %s still exists after uninstall", kind)
+			}
+		})
+	}
+
+	if err := g.InstallHookType("post-commit"); err == nil {
+		t.Error("InstallHookType(post-commit) error = nil, want error")
+	}
+}
+
+func TestInstallHookAppend(t *testing.T) {
+	dir := setupRepo(t)
+	target := filepath.Join(dir, ".git", "hooks", HookPrepareCommitMessageTemplate)
+
+	foreign := "#!/bin/sh\necho mine\n"
+	writeFile(t, target, foreign)
+
+	g := mustNew(t)
+	for i := 0; i < 2; i++ {
+		if err := g.InstallHookAppend(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(content)
+	if !strings.HasPrefix(got, foreign) {
+		t.Errorf("appended hook = %q, want it to keep %q", got, foreign)
+	}
+	if strings.Count(got, hookBlockBegin) != 1 || strings.Count(got, hookBlockEnd) != 1 {
+		t.Errorf("appended hook = %q, want exactly one zcode block", got)
+	}
+	if !strings.Contains(got, "commit --file") {
+		t.Errorf("appended hook = %q, want our hook body", got)
+	}
+
+	if err := g.UninstallHook(); err != nil {
+		t.Fatal(err)
+	}
+	content, err = os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != foreign {
+		t.Errorf("hook after uninstall = %q, want %q", content, foreign)
+	}
+}
+
+func TestInstallHookAppendFresh(t *testing.T) {
+	dir := setupRepo(t)
+	target := filepath.Join(dir, ".git", "hooks", HookPrepareCommitMessageTemplate)
+
+	if err := mustNew(t).InstallHookAppend(); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), hookBlockBegin) {
+		t.Errorf("fresh hook = %q, want a plain hook without markers", content)
+	}
+
+	if err := mustNew(t).UninstallHook(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Error("hook still exists after uninstall")
+	}
+}
+
+// runHook runs the hook script at target with a commit message file holding msg, reporting whether it accepted it.
+func runHook(t *testing.T, target, msg string) bool {
+	t.Helper()
+
+	msgFile := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	if err := os.WriteFile(msgFile, []byte(msg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return exec.Command("sh", target, msgFile).Run() == nil
+}
+
+func TestInstallCommitMsgValidator(t *testing.T) {
+	dir := setupRepo(t)
+	target := filepath.Join(dir, ".git", "hooks", string(HookCommitMsg))
+
+	if err := mustNew(t).InstallCommitMsgValidator(`^JIRA-[0-9]+: .+`); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]bool{
+		"JIRA-42: add login page\n\nbody\n": true,
+		"add login page\n":                  false,
+		"feat: add login page\n":            false,
+	}
+	for msg, want := range tests {
+		if got := runHook(t, target, msg); got != want {
+			t.Errorf("custom hook accepted %q = %v, want %v", msg, got, want)
+		}
+	}
+
+	if err := mustNew(t, WithForceHook(true)).InstallCommitMsgValidator(""); err != nil {
+		t.Fatal(err)
+	}
+	tests = map[string]bool{
+		"feat(git): add validator\n": true,
+		"fix!: drop old flag\n":      true,
+		"added a validator\n":        false,
+	}
+	for msg, want := range tests {
+		if got := runHook(t, target, msg); got != want {
+			t.Errorf("default hook accepted %q = %v, want %v", msg, got, want)
+		}
+	}
+
+	if err := mustNew(t, WithForceHook(true)).InstallCommitMsgValidator("(unclosed"); !errors.Is(err, errorsInvalidHookPattern) {
+		t.Errorf("InstallCommitMsgValidator() error = %v, want %v", err, errorsInvalidHookPattern)
+	}
+}
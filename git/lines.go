@@ -0,0 +1,133 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	errorsInvalidLineRange   = errors.New("invalid line range")
+	errorsFileNotChanged     = errors.New("file is not in the changeset")
+	errorsInvalidHunkPattern = errors.New("invalid hunk pattern")
+)
+
+// hunkHeader matches the new file range of a hunk header, e.g. "@@ -10,4 +12,6 @@".
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// DiffFileLines returns the diff of path, honoring the active range, reduced to the hunks
+// that touch lines start to end of the new version, counted from 1 and inclusive.
+// git diff has no -L option like git log does, so the hunks are picked from the file's patch.
+// The result is empty when path changed outside the range.
+func (c *Command) DiffFileLines(path string, start, end int) (string, error) {
+	if start < 1 || start > end {
+		return "", fmt.Errorf("%w: %d,%d", errorsInvalidLineRange, start, end)
+	}
+
+	ctx := context.Background()
+	files, err := c.changedFiles(ctx)
+	if err != nil {
+		return "", err
+	}
+	changed := false
+	for _, f := range files {
+		if f == path {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return "", fmt.Errorf("%w: %s", errorsFileNotChanged, path)
+	}
+
+	diff, err := c.diffBackend.diff(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, p := range splitPatches(diff) {
+		if p.path == path {
+			return hunksInRange(p.patch, start, end), nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", errorsFileNotChanged, path)
+}
+
+// hunksInRange returns the header of a single-file patch followed by its hunks
+// whose new lines overlap start to end, or an empty string when none do.
+func hunksInRange(patch string, start, end int) string {
+	return filterHunks(patch, func(hunk string) bool {
+		m := hunkHeader.FindStringSubmatch(hunk)
+		if m == nil {
+			return false
+		}
+		first, _ := strconv.Atoi(m[1])
+		count := 1
+		if m[2] != "" {
+			count, _ = strconv.Atoi(m[2])
+		}
+		// A hunk that only removes lines has no new lines; it sits after line first.
+		last := first + count - 1
+		if count == 0 {
+			last = first
+		}
+		return first <= end && last >= start
+	})
+}
+
+// DiffMatching returns the diff returned by DiffFiles reduced to the hunks with an added or removed line
+// matching the regular expression pattern. Files without such hunks are left out.
+func (c *Command) DiffMatching(pattern string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errorsInvalidHunkPattern, err)
+	}
+
+	diff, err := c.DiffFiles()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, p := range splitPatches(diff) {
+		b.WriteString(filterHunks(p.patch, func(hunk string) bool {
+			for _, line := range strings.Split(hunk, "\n")[1:] {
+				if (strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-")) && re.MatchString(line[1:]) {
+					return true
+				}
+			}
+			return false
+		}))
+	}
+	return b.String(), nil
+}
+
+// filterHunks returns the header of a single-file patch followed by its hunks that satisfy keep,
+// or an empty string when none do. Each hunk passed to keep starts with its "@@" line.
+func filterHunks(patch string, keep func(hunk string) bool) string {
+	var header strings.Builder
+	var hunks []string
+	for _, line := range strings.SplitAfter(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			hunks = append(hunks, line)
+		case len(hunks) == 0:
+			header.WriteString(line)
+		default:
+			hunks[len(hunks)-1] += line
+		}
+	}
+
+	var kept strings.Builder
+	for _, hunk := range hunks {
+		if keep(hunk) {
+			kept.WriteString(hunk)
+		}
+	}
+	if kept.Len() == 0 {
+		return ""
+	}
+	return header.String() + kept.String()
+}
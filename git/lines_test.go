@@ -0,0 +1,83 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiffFileLines(t *testing.T) {
+	setupRepo(t)
+	var before, after strings.Builder
+	for i := 1; i <= 40; i++ {
+		fmt.Fprintf(&before, "line %d\n", i)
+		if (i >= 10 && i <= 20) || i == 35 {
+			fmt.Fprintf(&after, "edited %d\n", i)
+		} else {
+			fmt.Fprintf(&after, "line %d\n", i)
+		}
+	}
+	commitFile(t, "a.txt", before.String(), "first")
+	commitFile(t, "b.txt", "b\n", "second")
+	writeFile(t, "a.txt", after.String())
+
+	g := mustNew(t, WithDiffUnified(3))
+	got, err := g.DiffFileLines("a.txt", 10, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(got, "\n@@ "); n != 1 {
+		t.Errorf("DiffFileLines() has %d hunks, want 1:\n%s", n, got)
+	}
+	if !strings.HasPrefix(got, "diff --git a/a.txt b/a.txt\n") || !strings.Contains(got, "+edited 15\n") || strings.Contains(got, "edited 35") {
+		t.Errorf("DiffFileLines() =\n%s\nwant the header and the hunk of lines 10-20 only", got)
+	}
+
+	if got, err := g.DiffFileLines("a.txt", 25, 30); err != nil || got != "" {
+		t.Errorf("DiffFileLines(25, 30) = %q, %v, want empty", got, err)
+	}
+	if _, err := g.DiffFileLines("a.txt", 20, 10); !errors.Is(err, errorsInvalidLineRange) {
+		t.Errorf("DiffFileLines(20, 10) error = %v, want %v", err, errorsInvalidLineRange)
+	}
+	if _, err := g.DiffFileLines("b.txt", 1, 1); !errors.Is(err, errorsFileNotChanged) {
+		t.Errorf("DiffFileLines(b.txt) error = %v, want %v", err, errorsFileNotChanged)
+	}
+}
+
+func TestDiffMatching(t *testing.T) {
+	setupRepo(t)
+	var before, after strings.Builder
+	for i := 1; i <= 40; i++ {
+		fmt.Fprintf(&before, "line %d\n", i)
+		switch i {
+		case 5:
+			fmt.Fprintf(&after, "func Login() {}\n")
+		case 30:
+			fmt.Fprintf(&after, "func Logout() {}\n")
+		default:
+			fmt.Fprintf(&after, "line %d\n", i)
+		}
+	}
+	commitFile(t, "a.go", before.String(), "first")
+	commitFile(t, "b.go", "x\n", "second")
+	writeFile(t, "a.go", after.String())
+	writeFile(t, "b.go", "y\n")
+
+	g := mustNew(t, WithDiffUnified(3))
+	got, err := g.DiffMatching(`^func Logout\(`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(got, "\n@@ "); n != 1 {
+		t.Errorf("DiffMatching() has %d hunks, want 1:\n%s", n, got)
+	}
+	if !strings.HasPrefix(got, "diff --git a/a.go b/a.go\n") || !strings.Contains(got, "+func Logout() {}\n") ||
+		strings.Contains(got, "Login") || strings.Contains(got, "b.go") {
+		t.Errorf("DiffMatching() =\n%s\nwant only the Logout hunk of a.go", got)
+	}
+
+	if _, err := g.DiffMatching("("); !errors.Is(err, errorsInvalidHunkPattern) {
+		t.Errorf("DiffMatching() error = %v, want %v", err, errorsInvalidHunkPattern)
+	}
+}
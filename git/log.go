@@ -0,0 +1,56 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var errorsNoCommitRange = errors.New("no commit range is configured")
+
+// RangeSubjects returns the subject lines of the commits in the active range, newest first,
+// such as those between the latest two tags, to summarize what a release contains.
+// With WithFirstParent only the mainline commits are listed, so a merged branch shows as its merge commit.
+// It fails for the working tree, the index and stash entries, which have no commits to list.
+func (c *Command) RangeSubjects() ([]string, error) {
+	ctx := context.Background()
+	revs, err := c.logRange(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"log", "--format=%s"}
+	if c.firstParent {
+		args = append(args, "--first-parent")
+	}
+	args = append(args, revs...)
+	output, err := c.output(c.gitCmd(ctx, append(args, "--")...))
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(string(output)), nil
+}
+
+// logRange converts the revisions compared by diffRange into git log revisions.
+// Symmetric "a...b" ranges become "a..b", the commits on b since it diverged from a,
+// and a root commit compared against the empty tree is listed on its own.
+func (c *Command) logRange(ctx context.Context) ([]string, error) {
+	if c.stashRef != "" {
+		return nil, errorsNoCommitRange
+	}
+	subcommand, revs, err := c.diffRange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(revs) == 0 || subcommand[len(subcommand)-1] == "--cached" {
+		return nil, errorsNoCommitRange
+	}
+
+	if len(revs) == 1 {
+		return []string{strings.Replace(revs[0], "...", "..", 1)}, nil
+	}
+	if c.verifyRef(ctx, revs[0]+"^{commit}") != nil {
+		return []string{revs[1]}, nil
+	}
+	return []string{revs[0] + ".." + revs[1]}, nil
+}
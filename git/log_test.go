@@ -0,0 +1,63 @@
+package git
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestRangeSubjects(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "feat: first")
+	tagAt(t, "v1.0.0", 1000)
+	runGit(t, "checkout", "-q", "-b", "feature")
+	commitFile(t, "b.txt", "b\n", "feat: side one")
+	commitFile(t, "c.txt", "c\n", "feat: side two")
+	runGit(t, "checkout", "-q", "-")
+	commitFile(t, "d.txt", "d\n", "fix: mainline")
+	runGit(t, "merge", "-q", "--no-ff", "-m", "Merge branch feature", "feature")
+	tagAt(t, "v1.1.0", 2000)
+
+	tests := []struct {
+		name        string
+		firstParent bool
+		want        []string
+	}{
+		{
+			name: "all commits",
+			want: []string{"Merge branch feature", "fix: mainline", "feat: side two", "feat: side one"},
+		},
+		{
+			name:        "first parent",
+			firstParent: true,
+			want:        []string{"Merge branch feature", "fix: mainline"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mustNew(t, WithDiffTagPrefix("v"), WithFirstParent(tt.firstParent)).RangeSubjects()
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Commits made within the same second have no defined order.
+			sort.Strings(got)
+			sort.Strings(tt.want)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RangeSubjects() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	got, err := mustNew(t, WithCommitId("v1.0.0")).RangeSubjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"feat: first"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RangeSubjects() of the root commit = %q, want %q", got, want)
+	}
+
+	if _, err := mustNew(t).RangeSubjects(); !errors.Is(err, errorsNoCommitRange) {
+		t.Errorf("RangeSubjects() of the working tree error = %v, want %v", err, errorsNoCommitRange)
+	}
+}
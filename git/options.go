@@ -1,5 +1,147 @@
 package git
 
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	errorsInvalidDiffAlgorithm  = errors.New("invalid diff algorithm")
+	errorsInvalidWhitespaceMode = errors.New("invalid whitespace mode")
+	errorsInvalidDiffTagPrefix  = errors.New("diff tag prefix contains shell metacharacters")
+	errorsInvalidAuthorEmail    = errors.New("invalid author email")
+	errorsInvalidBackend        = errors.New("invalid backend")
+	errorsInvalidTagSort        = errors.New("invalid tag sort key")
+	errorsInvalidTagPattern     = errors.New("invalid tag pattern")
+	errorsInvalidSubmoduleMode  = errors.New("invalid submodule mode")
+	errorsInvalidExcludeMagic   = errors.New("invalid exclude pathspec magic")
+	errorsInvalidDiffFilter     = errors.New("invalid diff filter")
+	errorsInvalidCoAuthor       = errors.New(`invalid co-author, want "Name <email>"`)
+	errorsInvalidExtraDiffArg   = errors.New("invalid extra diff argument")
+	errorsInvalidMergeParent    = errors.New("invalid merge parent, want 1 or more")
+	errorsInvalidCleanupMode    = errors.New("invalid commit message cleanup mode")
+	errorsInvalidTrailer        = errors.New("invalid trailer")
+	errorsInvalidExtension      = errors.New("invalid file extension")
+	errorsExtensionsWithPaths   = errors.New("WithOnlyExtensions cannot be combined with WithIncludeList or WithDiffList")
+	errorsIncludeWithDiffList   = errors.New("WithIncludeList cannot be combined with WithDiffList")
+	errorsAllowAndSkipEmpty     = errors.New("WithAllowEmpty cannot be combined with WithSkipIfEmpty")
+)
+
+// shellMetacharacters are rejected in values that must never reach a shell.
+const shellMetacharacters = "`$&|;<>()'\"\\!*?[]{}~# \t\r\n"
+
+const (
+	defaultRenameThreshold = 50
+	defaultDiffAlgorithm   = "minimal"
+	defaultWhitespaceMode  = "all"
+	defaultGitBinary       = "git"
+	defaultTagSort         = "creatordate"
+	defaultSubmoduleMode   = "short"
+	defaultExcludeMagic    = "exclude,top"
+)
+
+// diffAlgorithms is the set of values accepted by git diff --diff-algorithm.
+var diffAlgorithms = map[string]bool{
+	"minimal":   true,
+	"myers":     true,
+	"patience":  true,
+	"histogram": true,
+}
+
+// whitespaceFlags maps each whitespace mode to the git diff flag implementing it.
+var whitespaceFlags = map[string]string{
+	"all":    "--ignore-all-space",
+	"change": "--ignore-space-change",
+	"eol":    "--ignore-space-at-eol",
+	"none":   "",
+}
+
+// pathspecMagicWords are the pathspec magic words accepted by WithExcludePathspecMagic.
+var pathspecMagicWords = map[string]bool{
+	"exclude": true,
+	"glob":    true,
+	"icase":   true,
+	"literal": true,
+	"top":     true,
+}
+
+// diffFilterChars are the change types accepted by git diff --diff-filter.
+// Lowercase letters exclude a change type instead of selecting it.
+const diffFilterChars = "ACDMRTUXBacdmrtuxb*"
+
+// coAuthorPattern matches a "Name <email>" co-author entry.
+var coAuthorPattern = regexp.MustCompile(`^[^<>]*[^<>\s] <[^<>\s@]+@[^<>\s@]+>$`)
+
+// diffExtraFlags are the git diff flags accepted by WithExtraDiffArgs. They only change how each
+// patch is computed or laid out, never which files are diffed, where the output goes or its format,
+// so DiffFiles and ChangedFiles keep agreeing. Flags mapped to true take a value,
+// either as the next argument or attached to the flag.
+var diffExtraFlags = map[string]bool{
+	"--anchored":              true,
+	"--color=never":           false,
+	"--diff-algorithm":        true,
+	"--histogram":             false,
+	"--ignore-all-space":      false,
+	"--ignore-blank-lines":    false,
+	"--ignore-cr-at-eol":      false,
+	"--ignore-matching-lines": true,
+	"--ignore-space-at-eol":   false,
+	"--ignore-space-change":   false,
+	"--indent-heuristic":      false,
+	"--inter-hunk-context":    true,
+	"--minimal":               false,
+	"--no-indent-heuristic":   false,
+	"--patience":              false,
+	"-I":                      true,
+	"-O":                      true,
+	"-b":                      false,
+	"-w":                      false,
+}
+
+// diffAlgorithmFlags are the git diff flags that choose the diff algorithm, besides --diff-algorithm=<name>.
+var diffAlgorithmFlags = map[string]bool{
+	"--anchored":       true,
+	"--diff-algorithm": true,
+	"--histogram":      true,
+	"--minimal":        true,
+	"--patience":       true,
+}
+
+// cleanupModes is the set of values accepted by git commit --cleanup.
+var cleanupModes = map[string]bool{
+	"default":    true,
+	"scissors":   true,
+	"strip":      true,
+	"verbatim":   true,
+	"whitespace": true,
+}
+
+// trailerKeyPattern matches the keys accepted by WithExtraTrailers, such as "Reviewed-by".
+var trailerKeyPattern = regexp.MustCompile(`^[A-Za-z-]+$`)
+
+// submoduleModes is the set of values accepted by git diff --submodule.
+var submoduleModes = map[string]bool{
+	"diff":  true,
+	"log":   true,
+	"short": true,
+}
+
+// tagSortKeys is the set of git tag --sort keys accepted by WithTagSort.
+var tagSortKeys = map[string]bool{
+	"authordate":      true,
+	"committerdate":   true,
+	"creatordate":     true,
+	"objectname":      true,
+	"refname":         true,
+	"taggerdate":      true,
+	"v:refname":       true,
+	"version:refname": true,
+}
+
 // Option is an interface that specifies instrumentation configuration options.
 type Option interface {
 	apply(*config)
@@ -35,14 +177,82 @@ func WithExcludeList(val []string) Option {
 	})
 }
 
+// WithExcludeFile returns an Option that adds the exclude patterns listed in the file at path,
+// one per line, to the exclude list. Blank lines and lines starting with # are ignored.
+// A relative path is resolved against the working directory. New fails when the file cannot be read.
+func WithExcludeFile(path string) Option {
+	return optionFunc(func(c *config) {
+		c.excludeFile = path
+	})
+}
+
+// WithExcludePathspecMagic returns an Option that sets the pathspec magic words given to each exclude,
+// such as "exclude,top,icase" to exclude case-insensitively or "exclude" for paths relative to
+// the working directory. The default is "exclude,top". Excludes containing "**" always add glob.
+func WithExcludePathspecMagic(val string) Option {
+	return optionFunc(func(c *config) {
+		// If the given value is empty, keep the default.
+		if val == "" {
+			return
+		}
+		c.excludeMagic = val
+	})
+}
+
+// WithReplaceDefaultExcludes returns an Option that makes WithExcludeList replace DefaultExcludeFromDiff
+// instead of adding to them, so callers fully control which files are excluded.
+func WithReplaceDefaultExcludes(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.replaceDefaultExcludes = val
+	})
+}
+
+// WithNoDefaultExcludes returns an Option that stops excluding DefaultExcludeFromDiff,
+// so lock file changes can be audited, while excludes from WithExcludeList still apply.
+// WithReplaceDefaultExcludes has the same effect on the defaults, but is meant for callers
+// that pass their complete exclude list; this option only states that nothing is hidden by default.
+func WithNoDefaultExcludes() Option {
+	return optionFunc(func(c *config) {
+		c.noDefaultExcludes = true
+	})
+}
+
+// WithIncludeList returns an Option that restricts the diff to the given paths.
+// When an exclude list is also set, excludes apply within the included paths.
+func WithIncludeList(val []string) Option {
+	return optionFunc(func(c *config) {
+		c.includeList = val
+	})
+}
+
+// WithOnlyExtensions returns an Option that restricts the diff to files with the given extensions,
+// such as ".go" or "proto"; the leading dot is optional. Matching is case-sensitive.
+// It cannot be combined with WithIncludeList or WithDiffList.
+func WithOnlyExtensions(val []string) Option {
+	return optionFunc(func(c *config) {
+		c.extensions = val
+	})
+}
+
 // WithEnableAmend returns an Option that sets the isAmend field of a config object to the given value.
+// Diffs then compare HEAD against its parent, or against the empty tree when amending the root commit.
 func WithEnableAmend(val bool) Option {
 	return optionFunc(func(c *config) {
 		c.isAmend = val
 	})
 }
 
+// WithLastCommit returns an Option that compares the HEAD commit against its parent, the same as
+// git diff HEAD^ HEAD, to review what the last commit changed. Unlike WithEnableAmend,
+// commits are recorded as new commits rather than amending HEAD.
+func WithLastCommit(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.lastCommit = val
+	})
+}
+
 // WithDiffTagPrefix returns an Option that sets the diffTagPrefix field of a config object to the given value.
+// Prefixes containing shell metacharacters or newlines are rejected by New.
 func WithDiffTagPrefix(val string) Option {
 	return optionFunc(func(c *config) {
 		c.diffTagPrefix = val
@@ -50,10 +260,12 @@ func WithDiffTagPrefix(val string) Option {
 	})
 }
 
+// WithDiffList returns an Option that restricts the diff to exactly the given paths, in addition to the active range.
+// Unlike WithIncludeList, paths are taken literally, so wildcards in them match nothing else.
+// It cannot be combined with WithIncludeList.
 func WithDiffList(val []string) Option {
 	return optionFunc(func(c *config) {
 		c.diffList = val
-		//fmt.Printf("diffTagPrefix: %s\n", c.diffTagPrefix)
 	})
 }
 
@@ -64,12 +276,686 @@ func WithCommitId(val string) Option {
 	})
 }
 
+// WithMergeCommit returns an Option that compares a merge commit against its first parent,
+// the same as git diff <sha>^1 <sha>, to review the changes the merge brought in.
+// Use WithMergeParent to compare against another parent. Diffs fail with ErrNotMergeCommit when sha is not a merge commit.
+func WithMergeCommit(sha string) Option {
+	return optionFunc(func(c *config) {
+		c.mergeCommit = sha
+	})
+}
+
+// WithMergeParent returns an Option that sets which parent of the WithMergeCommit commit is compared against,
+// starting at 1. The default is 1, the branch that was merged into.
+func WithMergeParent(n int) Option {
+	return optionFunc(func(c *config) {
+		c.mergeParent = n
+	})
+}
+
+// WithCommitRange returns an Option that compares the changes between two commits.
+// When to is empty, the range from..HEAD is used instead.
+// An explicit commit range takes precedence over WithDiffTagPrefix and WithEnableAmend.
+func WithCommitRange(from, to string) Option {
+	return optionFunc(func(c *config) {
+		c.commitFrom = from
+		c.commitTo = to
+	})
+}
+
+// WithBranches returns an Option that compares head against the point where it diverged from base,
+// the same as git diff base...head.
+func WithBranches(base, head string) Option {
+	return optionFunc(func(c *config) {
+		c.baseBranch = base
+		c.headBranch = head
+	})
+}
+
+// WithUpstream returns an Option that compares HEAD against the point where it diverged from
+// the upstream branch of the current branch, the same as git diff @{upstream}...HEAD,
+// which is what a pull request from this branch would contain.
+// Diffs return ErrNoUpstream when no upstream branch is configured.
+func WithUpstream(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.upstream = val
+	})
+}
+
+// WithTagToHead returns an Option that compares the given tag against HEAD,
+// the same as git diff <tag> HEAD. The tag must exist.
+func WithTagToHead(tag string) Option {
+	return optionFunc(func(c *config) {
+		c.tagToHead = tag
+	})
+}
+
+// WithStash returns an Option that reviews the changes saved in a stash entry such as "stash@{0}",
+// the same as git stash show --patch, so a message can be written before applying it.
+// The stash entry must exist.
+func WithStash(ref string) Option {
+	return optionFunc(func(c *config) {
+		c.stashRef = ref
+	})
+}
+
+// WithTagSort returns an Option that sets the git tag --sort key used to find the latest tags,
+// such as "version:refname" for semantic version tags. Tags are sorted in descending order.
+// If the given value is empty, the default of creatordate is kept.
+func WithTagSort(val string) Option {
+	return optionFunc(func(c *config) {
+		if val == "" {
+			return
+		}
+		c.tagSort = val
+	})
+}
+
+// WithTagPattern returns an Option that only compares the latest two tags matching pattern,
+// such as "release/*" as a glob or `^v\d+\.\d+\.\d+$` as a regular expression when isRegex is true.
+// It can be combined with WithDiffTagPrefix, in which case tags must satisfy both.
+func WithTagPattern(pattern string, isRegex bool) Option {
+	return optionFunc(func(c *config) {
+		c.tagPattern = pattern
+		c.tagRegex = isRegex
+	})
+}
+
+// WithDetectRenames returns an Option that detects renamed files whose similarity
+// is at least threshold percent, so they show as a rename instead of a delete and an add.
+// A threshold of zero or less uses the default of 50 percent.
+func WithDetectRenames(threshold int) Option {
+	return optionFunc(func(c *config) {
+		if threshold <= 0 {
+			threshold = defaultRenameThreshold
+		}
+		c.renameThreshold = threshold
+	})
+}
+
+// WithDetectCopies returns an Option that also detects copied files when renames are detected.
+func WithDetectCopies(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.detectCopies = val
+	})
+}
+
+// WithRangeRenameDetection returns an Option that detects renames in diffs between revisions,
+// such as tag, commit and branch ranges, so a file renamed by any commit in the range shows as a rename
+// whatever the diff.renames setting. Working tree and staged diffs are unaffected.
+// WithDetectRenames sets the similarity threshold, which defaults to 50 percent.
+func WithRangeRenameDetection(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.rangeRenames = val
+	})
+}
+
+// WithFirstParent returns an Option that only follows the first parent of merge commits
+// when listing the commits of a range with RangeSubjects, the same as git log --first-parent,
+// so merge-heavy histories are summarized by their mainline commits.
+// Diffs are unaffected, since git diff compares the two ends of the range.
+func WithFirstParent(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.firstParent = val
+	})
+}
+
+// WithWordDiff returns an Option that shows changes word by word instead of line by line,
+// which is less noisy for prose and config files. The changed file names are unaffected.
+func WithWordDiff(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.wordDiff = val
+	})
+}
+
+// WithFunctionContext returns an Option that shows the whole enclosing function of each change
+// as context, the same as git diff --function-context. It is independent of WithDiffUnified.
+func WithFunctionContext(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.functionContext = val
+	})
+}
+
+// WithTextConv returns an Option that passes --textconv, so files whose diff driver has a textconv command
+// configured, such as diff.pdf.textconv for "*.pdf diff=pdf" in .gitattributes, are compared as readable text.
+// The default is false, which leaves the choice to git.
+func WithTextConv(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.textConv = val
+	})
+}
+
+// WithColor returns an Option that sets whether DiffFiles output keeps ANSI colors, for display in a terminal.
+// The default is false, which passes --color=never so that color settings in git config do not apply.
+// Colored output cannot be split into per-file patches, so do not combine it with
+// WithExcludeGenerated, WithOmitBinary or WithMaxFilePatchBytes.
+func WithColor(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.color = val
+	})
+}
+
+// WithSubmodules returns an Option that sets how changes to submodules are shown:
+// "short" shows the old and new commit hashes, "log" lists the commits in between
+// and "diff" shows the changes to the submodule's files. The default is "short".
+func WithSubmodules(mode string) Option {
+	return optionFunc(func(c *config) {
+		// If the given value is empty, keep the default.
+		if mode == "" {
+			return
+		}
+		c.submoduleMode = mode
+	})
+}
+
+// WithDiffFilter returns an Option that only shows changes of the given types, such as "A" for added files
+// or "AM" for added and modified files, the same as git diff --diff-filter.
+func WithDiffFilter(val string) Option {
+	return optionFunc(func(c *config) {
+		c.diffFilter = val
+	})
+}
+
+// WithNameStatus returns an Option that lists changed files with git diff --name-status
+// instead of --name-only, so each name comes with its status letter.
+// ChangedFiles still returns plain paths; ChangedFilesWithStatus returns the parsed statuses either way.
+func WithNameStatus(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.nameStatus = val
+	})
+}
+
+// WithOnlyRenames returns an Option that only shows files git reports as renamed or copied,
+// to audit large refactors. It replaces WithDiffFilter with "RC" and detects renames
+// with the default threshold unless WithDetectRenames sets one.
+func WithOnlyRenames(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.onlyRenames = val
+	})
+}
+
+// WithExtraDiffArgs returns an Option that passes extra arguments to git diff for flags this package
+// does not model, such as "--color=never". They follow the built-in flags and precede the revisions and pathspecs.
+// Only flags that change how each patch is computed, such as "--ignore-blank-lines" or "--patience", are accepted;
+// New rejects paths and flags that select other files, such as -S, write files or change the output format.
+// Arguments choosing the diff algorithm replace WithDiffAlgorithm.
+func WithExtraDiffArgs(val []string) Option {
+	return optionFunc(func(c *config) {
+		c.extraDiffArgs = val
+	})
+}
+
+// WithDiffAlgorithm returns an Option that sets the diff algorithm,
+// one of minimal, myers, patience or histogram. The default is minimal.
+func WithDiffAlgorithm(val string) Option {
+	return optionFunc(func(c *config) {
+		// If the given value is empty, keep the default.
+		if val == "" {
+			return
+		}
+		c.diffAlgorithm = val
+	})
+}
+
+// WithWhitespaceMode returns an Option that sets which whitespace changes are ignored:
+// all (the default), change, eol, or none to keep every whitespace change.
+func WithWhitespaceMode(val string) Option {
+	return optionFunc(func(c *config) {
+		// If the given value is empty, keep the default.
+		if val == "" {
+			return
+		}
+		c.whitespaceMode = val
+	})
+}
+
+// WithGitBinary returns an Option that sets the git executable to run. The default is git from PATH.
+func WithGitBinary(val string) Option {
+	return optionFunc(func(c *config) {
+		// If the given value is empty, keep the default.
+		if val == "" {
+			return
+		}
+		c.gitBinary = val
+	})
+}
+
+// WithEnv returns an Option that adds environment variables, in the form "key=value",
+// to the inherited environment of every git command.
+func WithEnv(val []string) Option {
+	return optionFunc(func(c *config) {
+		c.env = val
+	})
+}
+
+// WithWorkingDir returns an Option that runs every git command in the given directory
+// instead of the current working directory. New fails when the directory is not inside a git work tree.
+func WithWorkingDir(val string) Option {
+	return optionFunc(func(c *config) {
+		c.workingDir = val
+	})
+}
+
+// WithNoVerify returns an Option that sets whether commits bypass the pre-commit and commit-msg hooks.
+// The default is true.
+func WithNoVerify(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.noVerify = val
+	})
+}
+
+// WithSignoff returns an Option that sets whether commits get a Signed-off-by trailer.
+// The default is true.
+func WithSignoff(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.signoff = val
+	})
+}
+
+// WithAllowEmpty returns an Option that records commits even when nothing is staged,
+// the same as git commit --allow-empty.
+func WithAllowEmpty(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.allowEmpty = val
+	})
+}
+
+// WithSkipIfEmpty returns an Option that makes Commit, CommitWithBody and CommitFromFile
+// return ErrNothingToCommit without running git commit when nothing is staged.
+// Amending is not affected, since it can change the message alone.
+func WithSkipIfEmpty(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.skipIfEmpty = val
+	})
+}
+
+// WithNormalizeMessage returns an Option that sets whether commit messages have CRLF line endings
+// converted to LF and trailing whitespace trimmed from each line before they are passed to git.
+// Generated messages often carry both, which linters reject. The default is false.
+func WithNormalizeMessage(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.normalizeMessage = val
+	})
+}
+
+// WithMaxSubjectLength returns an Option that makes commits fail, before git runs,
+// when the first line of the message is longer than val characters.
+// The default is 0, which does not check the length.
+func WithMaxSubjectLength(val int) Option {
+	return optionFunc(func(c *config) {
+		c.maxSubjectLength = val
+	})
+}
+
+// WithMessageCleanup returns an Option that passes --cleanup=<mode> to git commit, choosing how
+// comment lines and whitespace in messages are handled: strip, whitespace, verbatim, scissors or default.
+// The default is empty, which leaves it to git: whitespace for messages passed on the command line.
+func WithMessageCleanup(mode string) Option {
+	return optionFunc(func(c *config) {
+		c.cleanupMode = mode
+	})
+}
+
+// WithSignCommit returns an Option that sets whether commits are GPG/SSH signed.
+func WithSignCommit(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.signCommit = val
+	})
+}
+
+// WithSigningKey returns an Option that signs commits with the given key instead of git's default key.
+// Setting a key enables commit signing.
+func WithSigningKey(val string) Option {
+	return optionFunc(func(c *config) {
+		c.signingKey = val
+	})
+}
+
+// WithAuthor returns an Option that overrides the author of commits.
+func WithAuthor(name, email string) Option {
+	return optionFunc(func(c *config) {
+		c.authorName = name
+		c.authorEmail = email
+	})
+}
+
+// WithCoAuthors returns an Option that credits each of val, formatted as "Name <email>",
+// with a Co-authored-by trailer on commits. Commits then need git 2.32.0 or later.
+func WithCoAuthors(val []string) Option {
+	return optionFunc(func(c *config) {
+		c.coAuthors = val
+	})
+}
+
+// WithExtraTrailers returns an Option that adds a "Key: Value" trailer to commits for each entry of val,
+// such as Reviewed-by or Refs, sorted by key. Keys may only contain letters and dashes.
+// Commits then need git 2.32.0 or later.
+func WithExtraTrailers(val map[string]string) Option {
+	return optionFunc(func(c *config) {
+		c.trailers = val
+	})
+}
+
+// WithCommitDate returns an Option that sets both the author and committer dates of commits.
+func WithCommitDate(val time.Time) Option {
+	return optionFunc(func(c *config) {
+		c.commitDate = val
+	})
+}
+
+// WithForceHook returns an Option that lets InstallHook overwrite an existing hook
+// that zcode installed before. Hooks authored by someone else are never overwritten.
+func WithForceHook(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.forceHook = val
+	})
+}
+
+// WithBackend returns an Option that selects how ChangedFiles and DiffFiles compute diffs.
+// The default is BackendExec.
+func WithBackend(val Backend) Option {
+	return optionFunc(func(c *config) {
+		// If the given value is empty, keep the default.
+		if val == "" {
+			return
+		}
+		c.backend = val
+	})
+}
+
+// WithMaxDiffBytes returns an Option that truncates the diff returned by DiffFiles to at most val bytes,
+// not counting the truncation notice. If val is zero or negative, the diff is not truncated.
+func WithMaxDiffBytes(val int) Option {
+	return optionFunc(func(c *config) {
+		c.maxDiffBytes = val
+	})
+}
+
+// WithMaxFilePatchBytes returns an Option that replaces the patch of each file larger than val bytes
+// in the DiffFiles output with a "[file omitted: N bytes changed]" placeholder, keeping its header,
+// so a single large file such as minified vendored code does not dominate the diff.
+// If val is zero or negative, every patch is kept.
+func WithMaxFilePatchBytes(val int) Option {
+	return optionFunc(func(c *config) {
+		c.maxPatchBytes = val
+	})
+}
+
+// WithExcludeGenerated returns an Option that drops files marked with a
+// "// Code generated ... DO NOT EDIT." comment from ChangedFiles and DiffFiles.
+// The marker is searched for near the start of each file's staged content, read with git show.
+func WithExcludeGenerated(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.excludeGenerated = val
+	})
+}
+
+// WithOmitBinary returns an Option that drops binary files from the DiffFiles output.
+// Use BinaryFiles to list them separately.
+func WithOmitBinary(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.omitBinary = val
+	})
+}
+
+// WithIncludeUntracked returns an Option that lets ChangedFiles and DiffFiles fall back to untracked files
+// when the working tree has no changes to tracked files and no commit range is configured.
+// Untracked files are diffed against an empty file with git diff --no-index, so the index is never modified.
+func WithIncludeUntracked(val bool) Option {
+	return optionFunc(func(c *config) {
+		c.includeUntracked = val
+	})
+}
+
+// WithRetry returns an Option that runs Commit and the diff commands up to attempts times
+// when git fails with a transient error, such as another process holding index.lock.
+// The wait between attempts starts at delay and doubles each time. Other errors fail immediately.
+func WithRetry(attempts int, delay time.Duration) Option {
+	return optionFunc(func(c *config) {
+		c.retryAttempts = attempts
+		c.retryDelay = delay
+	})
+}
+
+// WithTimeout returns an Option that kills every git invocation still running after val,
+// without callers having to pass a context. The error returned then wraps context.DeadlineExceeded.
+// If val is zero or negative, git runs until it exits or its context is done.
+func WithTimeout(val time.Duration) Option {
+	return optionFunc(func(c *config) {
+		c.timeout = val
+	})
+}
+
 // config is a struct that stores configuration options for the instrumentation.
 type config struct {
 	diffUnified   int
+	includeList   []string
 	excludeList   []string
 	isAmend       bool
+	lastCommit    bool
 	diffTagPrefix string
 	diffList      []string
 	commitId      string
+	mergeCommit   string
+	mergeParent   int
+	commitFrom    string
+	commitTo      string
+	baseBranch    string
+	headBranch    string
+	upstream      bool
+	tagToHead     string
+	stashRef      string
+	excludeMagic  string
+	excludeFile   string
+	tagSort       string
+	tagPattern    string
+	tagRegex      bool
+
+	renameThreshold  int
+	detectCopies     bool
+	rangeRenames     bool
+	firstParent      bool
+	wordDiff         bool
+	diffAlgorithm    string
+	whitespaceMode   string
+	gitBinary        string
+	env              []string
+	workingDir       string
+	noVerify         bool
+	signoff          bool
+	allowEmpty       bool
+	skipIfEmpty      bool
+	normalizeMessage bool
+	maxSubjectLength int
+	cleanupMode      string
+	signCommit       bool
+	signingKey       string
+	authorName       string
+	authorEmail      string
+	coAuthors        []string
+	trailers         map[string]string
+	commitDate       time.Time
+	forceHook        bool
+	backend          Backend
+	maxDiffBytes     int
+	maxPatchBytes    int
+	excludeGenerated bool
+	omitBinary       bool
+	includeUntracked bool
+	functionContext  bool
+	textConv         bool
+	color            bool
+	submoduleMode    string
+	retryAttempts    int
+	retryDelay       time.Duration
+	diffFilter       string
+	nameStatus       bool
+	onlyRenames      bool
+	extensions       []string
+	extraDiffArgs    []string
+	timeout          time.Duration
+
+	replaceDefaultExcludes bool
+	noDefaultExcludes      bool
+}
+
+// newConfig creates a new config object with default values, and applies the given options.
+func newConfig(opts ...Option) *config {
+	c := &config{
+		diffAlgorithm:  defaultDiffAlgorithm,
+		whitespaceMode: defaultWhitespaceMode,
+		gitBinary:      defaultGitBinary,
+		tagSort:        defaultTagSort,
+		submoduleMode:  defaultSubmoduleMode,
+		excludeMagic:   defaultExcludeMagic,
+		mergeParent:    1,
+		noVerify:       true,
+		signoff:        true,
+		backend:        BackendExec,
+	}
+
+	for _, opt := range opts {
+		opt.apply(c)
+	}
+
+	return c
+}
+
+// valid checks whether the config object is valid, returning an error if it is not.
+func (cfg *config) valid() error {
+	if !diffAlgorithms[cfg.diffAlgorithm] {
+		return fmt.Errorf("%w: %s", errorsInvalidDiffAlgorithm, cfg.diffAlgorithm)
+	}
+
+	if _, ok := whitespaceFlags[cfg.whitespaceMode]; !ok {
+		return fmt.Errorf("%w: %s", errorsInvalidWhitespaceMode, cfg.whitespaceMode)
+	}
+
+	if strings.ContainsAny(cfg.diffTagPrefix, shellMetacharacters) {
+		return fmt.Errorf("%w: %q", errorsInvalidDiffTagPrefix, cfg.diffTagPrefix)
+	}
+
+	if cfg.tagRegex {
+		if _, err := regexp.Compile(cfg.tagPattern); err != nil {
+			return fmt.Errorf("%w: %v", errorsInvalidTagPattern, err)
+		}
+	} else if _, err := path.Match(cfg.tagPattern, ""); err != nil {
+		return fmt.Errorf("%w: %v", errorsInvalidTagPattern, err)
+	}
+
+	if err := validExcludeMagic(cfg.excludeMagic); err != nil {
+		return err
+	}
+
+	if strings.Trim(cfg.diffFilter, diffFilterChars) != "" {
+		return fmt.Errorf("%w: %q", errorsInvalidDiffFilter, cfg.diffFilter)
+	}
+
+	if !submoduleModes[cfg.submoduleMode] {
+		return fmt.Errorf("%w: %s", errorsInvalidSubmoduleMode, cfg.submoduleMode)
+	}
+
+	if !tagSortKeys[cfg.tagSort] {
+		return fmt.Errorf("%w: %s", errorsInvalidTagSort, cfg.tagSort)
+	}
+
+	if cfg.backend != BackendExec && cfg.backend != BackendGoGit {
+		return fmt.Errorf("%w: %s", errorsInvalidBackend, cfg.backend)
+	}
+
+	if (cfg.authorName != "" || cfg.authorEmail != "") && !strings.Contains(cfg.authorEmail, "@") {
+		return fmt.Errorf("%w: %q", errorsInvalidAuthorEmail, cfg.authorEmail)
+	}
+
+	if cfg.cleanupMode != "" && !cleanupModes[cfg.cleanupMode] {
+		return fmt.Errorf("%w: %s", errorsInvalidCleanupMode, cfg.cleanupMode)
+	}
+
+	if cfg.allowEmpty && cfg.skipIfEmpty {
+		return errorsAllowAndSkipEmpty
+	}
+
+	if cfg.mergeParent < 1 {
+		return fmt.Errorf("%w: %d", errorsInvalidMergeParent, cfg.mergeParent)
+	}
+
+	if err := validExtraDiffArgs(cfg.extraDiffArgs); err != nil {
+		return err
+	}
+
+	// git matches any of several positive pathspecs, while selectsPath requires all lists to match.
+	if len(cfg.includeList) > 0 && len(cfg.diffList) > 0 {
+		return errorsIncludeWithDiffList
+	}
+	if len(cfg.extensions) > 0 && (len(cfg.includeList) > 0 || len(cfg.diffList) > 0) {
+		return errorsExtensionsWithPaths
+	}
+	for _, ext := range cfg.extensions {
+		if name := strings.TrimPrefix(ext, "."); name == "" || strings.ContainsAny(name, "/"+shellMetacharacters) {
+			return fmt.Errorf("%w: %q", errorsInvalidExtension, ext)
+		}
+	}
+
+	for _, coAuthor := range cfg.coAuthors {
+		if !coAuthorPattern.MatchString(coAuthor) {
+			return fmt.Errorf("%w: %q", errorsInvalidCoAuthor, coAuthor)
+		}
+	}
+
+	for key, value := range cfg.trailers {
+		if !trailerKeyPattern.MatchString(key) {
+			return fmt.Errorf("%w: key %q may only contain letters and dashes", errorsInvalidTrailer, key)
+		}
+		if strings.TrimSpace(value) == "" || strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("%w: value %q of %s must be a single non-empty line", errorsInvalidTrailer, value, key)
+		}
+	}
+
+	return nil
+}
+
+// validExtraDiffArgs returns an error unless every argument is a diffExtraFlags flag or the value of one.
+func validExtraDiffArgs(args []string) error {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if takesValue, ok := diffExtraFlags[arg]; ok {
+			if takesValue {
+				if i+1 == len(args) {
+					return fmt.Errorf("%w: %s needs a value", errorsInvalidExtraDiffArg, arg)
+				}
+				i++
+			}
+			continue
+		}
+		if !attachedDiffExtraValue(arg) {
+			return fmt.Errorf("%w: %q", errorsInvalidExtraDiffArg, arg)
+		}
+	}
+	return nil
+}
+
+// attachedDiffExtraValue reports whether arg is a diffExtraFlags flag with its value attached,
+// as in "--diff-algorithm=patience" or "-Sfoo".
+func attachedDiffExtraValue(arg string) bool {
+	if name, _, ok := strings.Cut(arg, "="); ok && strings.HasPrefix(name, "--") {
+		return diffExtraFlags[name]
+	}
+	return len(arg) > 2 && !strings.HasPrefix(arg, "--") && diffExtraFlags[arg[:2]]
+}
+
+// validExcludeMagic returns an error unless magic is a list of known pathspec magic words
+// that includes exclude, without which the pathspecs would select files instead.
+func validExcludeMagic(magic string) error {
+	for _, word := range strings.Split(magic, ",") {
+		if !pathspecMagicWords[word] {
+			return fmt.Errorf("%w: unknown word %q in %q", errorsInvalidExcludeMagic, word, magic)
+		}
+	}
+	if !hasMagicWord(magic, "exclude") {
+		return fmt.Errorf("%w: %q does not exclude", errorsInvalidExcludeMagic, magic)
+	}
+	if hasMagicWord(magic, "glob") && hasMagicWord(magic, "literal") {
+		return fmt.Errorf("%w: %q mixes glob and literal", errorsInvalidExcludeMagic, magic)
+	}
+	return nil
 }
@@ -0,0 +1,31 @@
+package git
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWithDiffTagPrefixValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		prefix  string
+		wantErr error
+	}{
+		{name: "empty", prefix: ""},
+		{name: "plain", prefix: "v"},
+		{name: "slash", prefix: "release/1."},
+		{name: "command separator", prefix: "'; rm -rf ~ #", wantErr: errorsInvalidDiffTagPrefix},
+		{name: "command substitution", prefix: "$(touch pwned)", wantErr: errorsInvalidDiffTagPrefix},
+		{name: "backticks", prefix: "`id`", wantErr: errorsInvalidDiffTagPrefix},
+		{name: "pipe", prefix: "v|sh", wantErr: errorsInvalidDiffTagPrefix},
+		{name: "newline", prefix: "v\nrm", wantErr: errorsInvalidDiffTagPrefix},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(WithDiffTagPrefix(tt.prefix))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("New() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package git
+
+import (
+	"strconv"
+	"strings"
+)
+
+// filePatch is the section of a diff that belongs to a single file.
+type filePatch struct {
+	path  string
+	patch string
+}
+
+// splitPatches splits the output of git diff into per-file patches,
+// keeping each file's header and hunks intact and in their original order.
+func splitPatches(diff string) []filePatch {
+	var patches []filePatch
+	for _, chunk := range splitOnHeaders(diff) {
+		patches = append(patches, filePatch{
+			path:  patchPath(chunk),
+			patch: chunk,
+		})
+	}
+	return patches
+}
+
+// filterPatches returns the per-file patches of diff whose path satisfies keep, in their original order.
+func filterPatches(diff string, keep func(path string) bool) string {
+	var b strings.Builder
+	for _, p := range splitPatches(diff) {
+		if keep(p.path) {
+			b.WriteString(p.patch)
+		}
+	}
+	return b.String()
+}
+
+// splitOnHeaders cuts diff before every "diff --git" header line.
+func splitOnHeaders(diff string) []string {
+	var chunks []string
+	start := -1
+	for i := 0; i < len(diff); {
+		end := strings.IndexByte(diff[i:], '\n')
+		if end == -1 {
+			end = len(diff)
+		} else {
+			end += i + 1
+		}
+		if strings.HasPrefix(diff[i:end], "diff --git ") {
+			if start >= 0 {
+				chunks = append(chunks, diff[start:i])
+			}
+			start = i
+		}
+		i = end
+	}
+	if start >= 0 {
+		chunks = append(chunks, diff[start:])
+	}
+	return chunks
+}
+
+// patchPath returns the path of the file a single-file patch applies to.
+// The new path is preferred, falling back to the old path for deletions.
+// Paths git quoted because of unusual characters are unquoted.
+func patchPath(patch string) string {
+	var header, oldPath string
+	for _, line := range strings.Split(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			header = strings.TrimPrefix(line, "diff --git ")
+		case strings.HasPrefix(line, "rename to "):
+			return unquotePath(strings.TrimPrefix(line, "rename to "))
+		case strings.HasPrefix(line, "copy to "):
+			return unquotePath(strings.TrimPrefix(line, "copy to "))
+		case strings.HasPrefix(line, "--- "):
+			if path, ok := strings.CutPrefix(unquotePath(strings.TrimPrefix(line, "--- ")), "a/"); ok {
+				oldPath = path
+			}
+		case strings.HasPrefix(line, "+++ "):
+			if path, ok := strings.CutPrefix(unquotePath(strings.TrimPrefix(line, "+++ ")), "b/"); ok {
+				return path
+			}
+		case strings.HasPrefix(line, "@@"):
+			if oldPath != "" {
+				return oldPath
+			}
+		}
+	}
+	if oldPath != "" {
+		return oldPath
+	}
+
+	// Binary and mode-only changes have no ---/+++ lines, and the header
+	// names the same path twice as "a/<path> b/<path>", each quoted if needed.
+	if quoted, err := strconv.QuotedPrefix(header); err == nil {
+		return strings.TrimPrefix(unquotePath(quoted), "a/")
+	}
+	if len(header) > 4 && strings.HasPrefix(header, "a/") {
+		return header[2 : (len(header)-1)/2]
+	}
+	return header
+}
+
+// unquotePath decodes a path from a patch line. git wraps paths with special or non-ASCII characters
+// in double quotes with C-style escapes, which Go string literals share, and ends ---/+++ lines
+// naming a path with a space with a tab.
+func unquotePath(path string) string {
+	path = strings.TrimSuffix(path, "\t")
+	if strings.HasPrefix(path, `"`) {
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			return unquoted
+		}
+	}
+	return path
+}
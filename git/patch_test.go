@@ -0,0 +1,147 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPatchPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+	}{
+		{
+			name:  "modified",
+			patch: "diff --git a/main.go b/main.go\nindex 1..2 100644\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-a\n+b\n",
+			want:  "main.go",
+		},
+		{
+			name:  "deleted",
+			patch: "diff --git a/old.go b/old.go\ndeleted file mode 100644\n--- a/old.go\n+++ /dev/null\n@@ -1 +0,0 @@\n-a\n",
+			want:  "old.go",
+		},
+		{
+			name:  "renamed",
+			patch: "diff --git a/a.go b/b.go\nsimilarity index 100%\nrename from a.go\nrename to b.go\n",
+			want:  "b.go",
+		},
+		{
+			name:  "quoted",
+			patch: "diff --git \"a/caf\\303\\251.txt\" \"b/caf\\303\\251.txt\"\nindex 1..2 100644\n--- \"a/caf\\303\\251.txt\"\n+++ \"b/caf\\303\\251.txt\"\n@@ -1 +1 @@\n-a\n+b\n",
+			want:  "café.txt",
+		},
+		{
+			name:  "quoted rename",
+			patch: "diff --git a/a.go \"b/t\\303\\251.go\"\nsimilarity index 100%\nrename from a.go\nrename to \"t\\303\\251.go\"\n",
+			want:  "té.go",
+		},
+		{
+			name:  "space",
+			patch: "diff --git a/my file.go b/my file.go\n--- a/my file.go\t\n+++ b/my file.go\t\n@@ -1 +1 @@\n-a\n+b\n",
+			want:  "my file.go",
+		},
+		{
+			name:  "quoted binary",
+			patch: "diff --git \"a/caf\\303\\251.png\" \"b/caf\\303\\251.png\"\nBinary files \"a/caf\\303\\251.png\" and \"b/caf\\303\\251.png\" differ\n",
+			want:  "café.png",
+		},
+		{
+			name:  "binary",
+			patch: "diff --git a/dir name/logo.png b/dir name/logo.png\nBinary files a/dir name/logo.png and b/dir name/logo.png differ\n",
+			want:  "dir name/logo.png",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := patchPath(tt.patch); got != tt.want {
+				t.Errorf("patchPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffFilesByPath(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "a")
+	commitFile(t, "pkg/b.txt", "b\n", "b")
+	writeFile(t, "a.txt", "a\nchanged\n")
+	writeFile(t, "pkg/b.txt", "b\nchanged\n")
+
+	g := mustNew(t)
+	files, err := g.DiffFilesByPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	var joined string
+	for _, p := range []string{"a.txt", "pkg/b.txt"} {
+		if patch, ok := files[p]; ok {
+			paths = append(paths, p)
+			joined += patch
+		}
+	}
+	if len(files) != 2 || !reflect.DeepEqual(paths, []string{"a.txt", "pkg/b.txt"}) {
+		t.Fatalf("DiffFilesByPath() keys = %v, want [a.txt pkg/b.txt]", files)
+	}
+
+	diff, err := g.DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if joined != diff {
+		t.Errorf("joined patches = %q, want %q", joined, diff)
+	}
+}
+
+func TestDiffFilesByPathNonASCII(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "café.txt", "a\n", "add text")
+	commitFile(t, "logo é.png", "\x00\x01", "add image")
+	writeFile(t, "café.txt", "b\n")
+	writeFile(t, "logo é.png", "\x00\x02")
+
+	files, err := mustNew(t).DiffFilesByPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || !strings.Contains(files["café.txt"], "+b") || !strings.Contains(files["logo é.png"], "Binary files") {
+		t.Errorf("DiffFilesByPath() = %q, want patches keyed by café.txt and logo é.png", files)
+	}
+}
+
+func TestDiffByTopDir(t *testing.T) {
+	setupRepo(t)
+	for _, name := range []string{"a/one.txt", "a/sub/two.txt", "b/three.txt", "root.txt"} {
+		commitFile(t, name, "old\n", "add "+name)
+		writeFile(t, name, "new "+name+"\n")
+	}
+
+	dirs, err := mustNew(t).DiffByTopDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dirs) != 3 {
+		t.Fatalf("DiffByTopDir() has %d buckets, want 3: %v", len(dirs), dirs)
+	}
+
+	want := map[string][]string{
+		"a": {"+new a/one.txt", "+new a/sub/two.txt"},
+		"b": {"+new b/three.txt"},
+		".": {"+new root.txt"},
+	}
+	for dir, lines := range want {
+		patches := splitPatches(dirs[dir])
+		if len(patches) != len(lines) {
+			t.Errorf("bucket %q has %d patches, want %d:\n%s", dir, len(patches), len(lines), dirs[dir])
+			continue
+		}
+		for _, line := range lines {
+			if !strings.Contains(dirs[dir], line) {
+				t.Errorf("bucket %q is missing %q:\n%s", dir, line, dirs[dir])
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package git
+
+import (
+	"regexp"
+	"strings"
+)
+
+// selectsPath reports whether path, relative to the repository root, is selected by the include
+// and diff lists and the extensions, and not removed by the exclude list, mirroring the pathspecs passed to git.
+func (c *Command) selectsPath(path string) bool {
+	if len(c.includeList) > 0 {
+		included := false
+		for _, f := range c.includeList {
+			if matchPathspec(f, path, false) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+
+	if len(c.diffList) > 0 {
+		listed := false
+		for _, f := range c.diffList {
+			f = strings.TrimSuffix(strings.TrimPrefix(f, "/"), "/")
+			if path == f || strings.HasPrefix(path, f+"/") {
+				listed = true
+				break
+			}
+		}
+		if !listed {
+			return false
+		}
+	}
+
+	if len(c.extensions) > 0 && !c.hasExtension(path) {
+		return false
+	}
+
+	return !c.IsExcluded(path)
+}
+
+// hasExtension reports whether path ends with one of the extensions set by WithOnlyExtensions.
+func (c *Command) hasExtension(path string) bool {
+	for _, ext := range c.extensions {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectPaths returns the paths selected by selectsPath, keeping their order.
+func (c *Command) selectPaths(paths []string) []string {
+	var selected []string
+	for _, p := range paths {
+		if c.selectsPath(p) {
+			selected = append(selected, p)
+		}
+	}
+	return selected
+}
+
+// hasMagicWord reports whether the comma-separated pathspec magic contains word.
+func hasMagicWord(magic, word string) bool {
+	for _, w := range strings.Split(magic, ",") {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPathspec reports whether path matches pattern the way git matches a pathspec.
+// A pattern without wildcards matches the path itself or anything below it.
+// Wildcards match like fnmatch; "*" crosses directory boundaries unless glob is set,
+// in which case "**" is needed to match across directories.
+func matchPathspec(pattern, path string, glob bool) bool {
+	pattern = strings.TrimPrefix(pattern, "/")
+	if !strings.ContainsAny(pattern, "*?[") {
+		pattern = strings.TrimSuffix(pattern, "/")
+		return path == pattern || strings.HasPrefix(path, pattern+"/")
+	}
+
+	re, err := regexp.Compile(pathspecRegexp(pattern, glob))
+	if err != nil {
+		return false
+	}
+
+	// a pattern matching a directory also matches everything below it
+	for p := path; p != "."; {
+		if re.MatchString(p) {
+			return true
+		}
+		i := strings.LastIndexByte(p, '/')
+		if i == -1 {
+			break
+		}
+		p = p[:i]
+	}
+	return false
+}
+
+// pathspecRegexp converts a wildcard pattern to an anchored regular expression.
+func pathspecRegexp(pattern string, glob bool) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; {
+		case glob && strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case glob && strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case ch == '*' && glob:
+			b.WriteString("[^/]*")
+		case ch == '*':
+			b.WriteString(".*")
+		case ch == '?' && glob:
+			b.WriteString("[^/]")
+		case ch == '?':
+			b.WriteString(".")
+		case ch == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end == -1 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
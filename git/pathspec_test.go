@@ -0,0 +1,53 @@
+package git
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMatchPathspec(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		glob    bool
+		want    bool
+	}{
+		{pattern: "go.sum", path: "go.sum", want: true},
+		{pattern: "go.sum", path: "sub/go.sum", want: false},
+		{pattern: "vendor", path: "vendor/lib/a.go", want: true},
+		{pattern: "vendor", path: "vendored.go", want: false},
+		{pattern: "*.lock", path: "yarn.lock", want: true},
+		{pattern: "*.lock", path: "sub/Cargo.lock", want: true},
+		{pattern: "vendor/**", path: "vendor/lib/a.go", glob: true, want: true},
+		{pattern: "vendor/**", path: "src/vendor/a.go", glob: true, want: false},
+		{pattern: "**/*.generated.go", path: "api.generated.go", glob: true, want: true},
+		{pattern: "**/*.generated.go", path: "pkg/api.generated.go", glob: true, want: true},
+		{pattern: "**/*.generated.go", path: "pkg/api.go", glob: true, want: false},
+		{pattern: "pkg/*/a.go", path: "pkg/x/y/a.go", glob: true, want: false},
+		{pattern: "pkg/[ab].go", path: "pkg/b.go", want: true},
+		{pattern: "pkg/[!ab].go", path: "pkg/b.go", want: false},
+	}
+	for _, tt := range tests {
+		if got := matchPathspec(tt.pattern, tt.path, tt.glob); got != tt.want {
+			t.Errorf("matchPathspec(%q, %q, %v) = %v, want %v", tt.pattern, tt.path, tt.glob, got, tt.want)
+		}
+	}
+}
+
+func TestIncludeWithDiffList(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "other.go", "package other\n", "add other")
+	commitFile(t, "pkg/a.go", "package pkg\n", "add pkg")
+
+	for _, backend := range []Backend{BackendExec, BackendGoGit} {
+		_, err := New(
+			WithBackend(backend),
+			WithLastCommit(true),
+			WithIncludeList([]string{"pkg"}),
+			WithDiffList([]string{"other.go"}),
+		)
+		if !errors.Is(err, errorsIncludeWithDiffList) {
+			t.Errorf("%s: New() error = %v, want %v", backend, err, errorsIncludeWithDiffList)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DiffFilesReader returns the diff over the same range and excludes used by DiffFiles as a stream,
+// so very large diffs never have to fit in memory. The caller must close the reader,
+// which waits for git to exit and reports its failure if the stream was read to the end.
+// It honors WithStash and WithIncludeUntracked like DiffFiles, but those diffs are built in memory.
+// Otherwise the output is git's unprocessed diff: WithExcludeGenerated, WithOmitBinary and WithMaxDiffBytes
+// are not applied, and an empty diff yields an empty stream rather than an error.
+func (c *Command) DiffFilesReader() (io.ReadCloser, error) {
+	return c.diffFilesReader(context.Background())
+}
+
+// WriteDiff streams the diff returned by DiffFilesReader to w, such as a file, a buffer or a pager's stdin,
+// without holding it in memory. The same processing caveats as DiffFilesReader apply.
+func (c *Command) WriteDiff(w io.Writer) error {
+	r, err := c.diffFilesReader(context.Background())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		_ = r.Close()
+		return err
+	}
+	return r.Close()
+}
+
+func (c *Command) diffFilesReader(ctx context.Context) (io.ReadCloser, error) {
+	if c.includeUntracked {
+		files, untracked, err := c.selectFiles(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if untracked {
+			diff, err := c.untrackedDiff(ctx, files)
+			if err != nil {
+				return nil, err
+			}
+			return io.NopCloser(strings.NewReader(diff)), nil
+		}
+	}
+
+	// git stash show takes no pathspecs, so its output is filtered in memory like DiffFiles does.
+	if _, ok := c.diffBackend.(execDiffer); !ok || c.stashRef != "" {
+		diff, err := c.diffBackend.diff(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(strings.NewReader(diff)), nil
+	}
+
+	cmd, err := c.diffFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	r := &diffReader{ReadCloser: stdout, cmd: cmd}
+	cmd.Stderr = &r.stderr
+	r.stop = cmd.startTimeout()
+	if err := cmd.Start(); err != nil {
+		r.stop()
+		return nil, err
+	}
+	return r, nil
+}
+
+// diffReader streams the standard output of a running git command.
+type diffReader struct {
+	io.ReadCloser
+	cmd    *gitCommand
+	stop   func()
+	stderr bytes.Buffer
+	eof    bool
+}
+
+func (r *diffReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if errors.Is(err, io.EOF) {
+		r.eof = true
+	}
+	return n, err
+}
+
+// Close stops reading and waits for git to exit.
+// Closing before the end of the stream makes git exit early, which is not reported as an error.
+func (r *diffReader) Close() error {
+	_ = r.ReadCloser.Close()
+	err := r.cmd.Wait()
+	defer r.stop()
+	if err == nil {
+		return nil
+	}
+	if r.cmd.timedOut() {
+		return fmt.Errorf("git %s: %w", r.cmd.Args[1], context.DeadlineExceeded)
+	}
+	if !r.eof {
+		return nil
+	}
+	if msg := strings.TrimSpace(r.stderr.String()); msg != "" {
+		err = fmt.Errorf("%w: %s", err, msg)
+	}
+	return err
+}
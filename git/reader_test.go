@@ -0,0 +1,112 @@
+package git
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDiffFilesReader(t *testing.T) {
+	setupRepo(t)
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		commitFile(t, name, strings.Repeat(name+"\n", 100), "add "+name)
+		writeFile(t, name, strings.Repeat(name+" changed\n", 100))
+	}
+
+	g := mustNew(t)
+	want, err := g.DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := g.DiffFilesReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got strings.Builder
+	buf := make([]byte, 7)
+	for {
+		n, err := r.Read(buf)
+		got.Write(buf[:n])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.String() != want {
+		t.Errorf("streamed diff differs from DiffFiles():\ngot  %q\nwant %q", got.String(), want)
+	}
+}
+
+func TestDiffFilesReaderEarlyClose(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "add a")
+	writeFile(t, "a.txt", strings.Repeat("changed\n", 100000))
+
+	r, err := mustNew(t).DiffFilesReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Read(make([]byte, 16)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf("Close() before the end of the stream = %v, want nil", err)
+	}
+}
+
+func TestWriteDiff(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "one\n", "add a.txt")
+	commitFile(t, "b.txt", "two\n", "add b.txt")
+	writeFile(t, "a.txt", "one changed\n")
+	writeFile(t, "b.txt", "two changed\n")
+
+	g := mustNew(t)
+	want, err := g.DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := g.WriteDiff(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != want {
+		t.Errorf("WriteDiff() wrote\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestDiffFilesReaderMatchesDiffFiles(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "one\n", "add a")
+	commitFile(t, "go.sum", "sum\n", "add go.sum")
+	writeFile(t, "a.txt", "stashed change\n")
+	writeFile(t, "go.sum", "changed sum\n")
+	runGit(t, "stash", "-q")
+	writeFile(t, "new.txt", "untracked\n")
+
+	for _, g := range []*Command{
+		mustNew(t, WithStash("stash@{0}")),
+		mustNew(t, WithIncludeUntracked(true)),
+	} {
+		want, err := g.DiffFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got bytes.Buffer
+		if err := g.WriteDiff(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != want {
+			t.Errorf("WriteDiff() =\n%s\nwant the DiffFiles() output\n%s", got.String(), want)
+		}
+	}
+}
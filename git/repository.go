@@ -0,0 +1,22 @@
+package git
+
+// Differ returns the diff a commit message is generated from.
+type Differ interface {
+	DiffFiles() (string, error)
+}
+
+// Committer records the staged changes with a message.
+type Committer interface {
+	Commit(val string) (string, error)
+}
+
+// Repository is the part of Command used to generate and record commit messages.
+// Code depending on it instead of *Command can be tested with a fake implementation.
+type Repository interface {
+	Differ
+	Committer
+	GitDir() (string, error)
+	InstallHook() error
+}
+
+var _ Repository = (*Command)(nil)
@@ -0,0 +1,56 @@
+package git
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeRepository records commits in memory instead of running git.
+type fakeRepository struct {
+	diff    string
+	commits []string
+}
+
+func (f *fakeRepository) DiffFiles() (string, error) {
+	if f.diff == "" {
+		return "", ErrNoStagedChanges
+	}
+	return f.diff, nil
+}
+
+func (f *fakeRepository) Commit(val string) (string, error) {
+	f.commits = append(f.commits, val)
+	return "[main abc1234] " + val, nil
+}
+
+func (f *fakeRepository) GitDir() (string, error) { return "/fake/.git", nil }
+func (f *fakeRepository) InstallHook() error      { return nil }
+
+// commitSummary commits a message naming the number of lines in the diff,
+// standing in for code that depends on Repository.
+func commitSummary(r Repository) (string, error) {
+	diff, err := r.DiffFiles()
+	if err != nil {
+		return "", err
+	}
+	return r.Commit("chore: update " + strings.Repeat("+", strings.Count(diff, "\n")))
+}
+
+func TestRepositoryFake(t *testing.T) {
+	fake := &fakeRepository{diff: "+a\n+b\n"}
+	out, err := commitSummary(fake)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "chore: update ++"; len(fake.commits) != 1 || fake.commits[0] != want {
+		t.Errorf("commits = %q, want [%q]", fake.commits, want)
+	}
+	if !strings.Contains(out, "chore: update ++") {
+		t.Errorf("commitSummary() = %q, want the commit output", out)
+	}
+
+	if _, err := commitSummary(&fakeRepository{}); !errors.Is(err, ErrNoStagedChanges) {
+		t.Errorf("commitSummary() error = %v, want %v", err, ErrNoStagedChanges)
+	}
+}
@@ -0,0 +1,164 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DiffResult is the machine-readable form of a diff.
+type DiffResult struct {
+	// Range is the revisions that were compared, empty for the working tree against the index.
+	Range string     `json:"range"`
+	Files []FileDiff `json:"files"`
+}
+
+// FileDiff describes the changes made to a single file.
+type FileDiff struct {
+	Path      string `json:"path"`
+	Status    string `json:"status"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Patch     string `json:"patch"`
+}
+
+// FileChange is a changed file and its git diff --name-status letter:
+// A, C, D, M, R, T or U for added, copied, deleted, modified, renamed, type-changed or unmerged.
+type FileChange struct {
+	Path   string
+	Status string
+	// OldPath is the path before a rename or copy, empty otherwise.
+	OldPath string
+}
+
+// fileStatuses maps the status letters of git diff --name-status to readable strings.
+var fileStatuses = map[byte]string{
+	'A': "added",
+	'C': "copied",
+	'D': "deleted",
+	'M': "modified",
+	'R': "renamed",
+	'T': "type-changed",
+	'U': "unmerged",
+}
+
+// DiffJSON returns the diff over the same range and excludes used by DiffFiles
+// as a DiffResult, ready to be encoded as JSON.
+func (c *Command) DiffJSON() (DiffResult, error) {
+	ctx := context.Background()
+
+	_, revs, err := c.diffRange(ctx)
+	if err != nil {
+		return DiffResult{}, err
+	}
+	result := DiffResult{
+		Range: strings.Join(revs, " "),
+		Files: []FileDiff{},
+	}
+
+	changes, err := c.diffNameStatus(ctx)
+	if err != nil {
+		return DiffResult{}, err
+	}
+	numstat, err := c.diffNumstat(ctx)
+	if err != nil {
+		return DiffResult{}, err
+	}
+	// Both listings come from the same diff, so they name the files in the same order.
+	if len(changes) != len(numstat) {
+		return DiffResult{}, fmt.Errorf("name-status lists %d files but numstat lists %d", len(changes), len(numstat))
+	}
+
+	diff, err := execDiffer{c}.diff(ctx)
+	if err != nil {
+		return DiffResult{}, err
+	}
+	patches := make(map[string]string)
+	for _, p := range splitPatches(diff) {
+		patches[p.path] += p.patch
+	}
+
+	for i, change := range changes {
+		status, ok := fileStatuses[change.Status[0]]
+		if !ok {
+			status = change.Status
+		}
+		result.Files = append(result.Files, FileDiff{
+			Path:      change.Path,
+			Status:    status,
+			Additions: numstat[i].insertions,
+			Deletions: numstat[i].deletions,
+			Patch:     patches[change.Path],
+		})
+	}
+
+	return result, nil
+}
+
+// ChangedFilesWithStatus returns the files changed over the same range and excludes used by DiffFiles,
+// with their status letters. Renames and copies also carry the old path.
+func (c *Command) ChangedFilesWithStatus() ([]FileChange, error) {
+	return c.diffNameStatus(context.Background())
+}
+
+// diffNameStatus returns the changed files listed by git diff --name-status.
+func (c *Command) diffNameStatus(ctx context.Context) ([]FileChange, error) {
+	fields, err := c.diffFields(ctx, "--name-status")
+	if err != nil {
+		return nil, err
+	}
+	changes, err := parseNameStatus(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.stashRef == "" {
+		return changes, nil
+	}
+	// git stash show cannot filter by path itself.
+	var selected []FileChange
+	for _, change := range changes {
+		if c.selectsPath(change.Path) {
+			selected = append(selected, change)
+		}
+	}
+	return selected, nil
+}
+
+// parseNameStatus parses the fields of git diff --name-status -z, such as "M", "main.go"
+// or "R100", "old.go", "new.go". The similarity score of renames and copies is dropped from the status.
+func parseNameStatus(fields []string) ([]FileChange, error) {
+	var changes []FileChange
+	for i := 0; i < len(fields); {
+		status := fields[i]
+		// Renames and copies list the old path first and the new path last.
+		paths := 1
+		if strings.HasPrefix(status, "R") || strings.HasPrefix(status, "C") {
+			paths = 2
+		}
+		if status == "" || i+paths >= len(fields) {
+			return nil, fmt.Errorf("invalid name-status output: %q", strings.Join(fields[i:], "\x00"))
+		}
+
+		change := FileChange{Status: status[:1], Path: fields[i+paths]}
+		if paths == 2 {
+			change.OldPath = fields[i+1]
+		}
+		changes = append(changes, change)
+		i += paths + 1
+	}
+	return changes, nil
+}
+
+// diffFields runs git diff -z with the given flags and returns its NUL-terminated fields.
+func (c *Command) diffFields(ctx context.Context, flags ...string) ([]string, error) {
+	cmd, err := c.diffCmd(ctx, append(flags, "-z")...)
+	if err != nil {
+		return nil, err
+	}
+	output, err := c.diffOutput(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return splitNUL(string(output)), nil
+}
@@ -0,0 +1,96 @@
+package git
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffJSON(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "modified.txt", "one\ntwo\n", "add modified")
+	commitFile(t, "deleted.txt", "gone\n", "add deleted")
+	commitFile(t, "old.txt", "a\nb\nc\nd\ne\n", "add old")
+	from := runGit(t, "rev-parse", "HEAD")
+
+	writeFile(t, "modified.txt", "one\n2\nthree\n")
+	writeFile(t, "added.txt", "new\n")
+	runGit(t, "rm", "-q", "deleted.txt")
+	runGit(t, "mv", "old.txt", "new.txt")
+	runGit(t, "add", "-A")
+	runGit(t, "commit", "-q", "-m", "mixed")
+
+	got, err := mustNew(t, WithCommitRange(from, "HEAD"), WithDetectRenames(50)).DiffJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Range != from+" HEAD" {
+		t.Errorf("Range = %q, want %q", got.Range, from+" HEAD")
+	}
+
+	want := map[string]FileDiff{
+		"added.txt":    {Path: "added.txt", Status: "added", Additions: 1},
+		"deleted.txt":  {Path: "deleted.txt", Status: "deleted", Deletions: 1},
+		"modified.txt": {Path: "modified.txt", Status: "modified", Additions: 2, Deletions: 1},
+		"new.txt":      {Path: "new.txt", Status: "renamed"},
+	}
+	if len(got.Files) != len(want) {
+		t.Fatalf("got %d files, want %d: %+v", len(got.Files), len(want), got.Files)
+	}
+	for _, f := range got.Files {
+		w, ok := want[f.Path]
+		if !ok {
+			t.Errorf("unexpected file %q", f.Path)
+			continue
+		}
+		if !strings.HasPrefix(f.Patch, "diff --git ") {
+			t.Errorf("%s: patch = %q, want a git patch", f.Path, f.Patch)
+		}
+		f.Patch = ""
+		if f != w {
+			t.Errorf("got %+v, want %+v", f, w)
+		}
+	}
+
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded DiffResult
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, got) {
+		t.Errorf("round trip = %+v, want %+v", decoded, got)
+	}
+}
+
+func TestChangedFilesWithStatus(t *testing.T) {
+	setupRepo(t)
+	content := "line 1\nline 2\nline 3\nline 4\nline 5\n"
+	commitFile(t, "modified.txt", "a\n", "first")
+	commitFile(t, "deleted.txt", "d\n", "second")
+	commitFile(t, "old.txt", content, "third")
+
+	writeFile(t, "modified.txt", "b\n")
+	writeFile(t, "added.txt", "new\n")
+	runGit(t, "rm", "-q", "deleted.txt")
+	runGit(t, "mv", "old.txt", "new.txt")
+	runGit(t, "add", ".")
+	runGit(t, "commit", "-q", "-m", "change")
+
+	got, err := mustNew(t, WithDetectRenames(0), WithLastCommit(true)).ChangedFilesWithStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FileChange{
+		{Path: "added.txt", Status: "A"},
+		{Path: "deleted.txt", Status: "D"},
+		{Path: "modified.txt", Status: "M"},
+		{Path: "new.txt", Status: "R", OldPath: "old.txt"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ChangedFilesWithStatus() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,47 @@
+package git
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// transientErrors are fragments of git error messages caused by another process holding a lock,
+// which usually succeed when tried again.
+var transientErrors = []string{
+	"index.lock",
+	"unable to create",
+}
+
+// isTransient reports whether err looks like a temporary git failure worth retrying.
+func isTransient(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, fragment := range transientErrors {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// retry calls run up to retryAttempts times while it fails with a transient error,
+// doubling the wait between attempts starting from retryDelay.
+// run must build a new exec.Cmd on every call, since a command can only be started once.
+func (c *Command) retry(ctx context.Context, run func() ([]byte, error)) ([]byte, error) {
+	delay := c.retryDelay
+	for attempt := 1; ; attempt++ {
+		output, err := run()
+		if err == nil || attempt >= c.retryAttempts || !isTransient(err) {
+			return output, err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
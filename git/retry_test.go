@@ -0,0 +1,86 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flakyGit returns a git wrapper that fails with stderr message for the first failures invocations
+// of subcommand, and the file counting every invocation of it.
+func flakyGit(t *testing.T, subcommand string, failures int, message string) (binary, counter string) {
+	t.Helper()
+
+	counter = filepath.Join(t.TempDir(), "count")
+	binary = fakeGit(t, `if [ "$1" = "`+subcommand+`" ]; then
+	n=$(($(cat `+counter+` 2>/dev/null || echo 0) + 1))
+	echo $n > `+counter+`
+	if [ $n -le `+strconv.Itoa(failures)+` ]; then
+		echo "`+message+`" >&2
+		exit 128
+	fi
+fi`)
+	return binary, counter
+}
+
+func invocations(t *testing.T, counter string) string {
+	t.Helper()
+
+	content, err := os.ReadFile(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(content))
+}
+
+func TestWithRetry(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "a.txt", "b\n")
+	runGit(t, "add", "a.txt")
+
+	binary, counter := flakyGit(t, "commit", 2, "fatal: Unable to create '.git/index.lock': File exists.")
+	_, err := mustNew(t, WithGitBinary(binary), WithRetry(3, time.Millisecond)).Commit("retried")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := invocations(t, counter); got != "3" {
+		t.Errorf("git commit ran %s times, want 3", got)
+	}
+	if got := runGit(t, "log", "-1", "--format=%s"); got != "retried" {
+		t.Errorf("last commit = %q, want %q", got, "retried")
+	}
+}
+
+func TestWithRetryExhausted(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "a.txt", "b\n")
+
+	binary, counter := flakyGit(t, "diff", 5, "fatal: Unable to create '.git/index.lock': File exists.")
+	_, err := mustNew(t, WithGitBinary(binary), WithRetry(2, time.Millisecond)).DiffFiles()
+	if err == nil || !strings.Contains(err.Error(), "index.lock") {
+		t.Errorf("DiffFiles() error = %v, want the index.lock error", err)
+	}
+	if got := invocations(t, counter); got != "2" {
+		t.Errorf("git diff ran %s times, want 2", got)
+	}
+}
+
+func TestWithRetryNonTransient(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "a.txt", "b\n")
+	runGit(t, "add", "a.txt")
+
+	binary, counter := flakyGit(t, "commit", 1, "fatal: bad object HEAD")
+	if _, err := mustNew(t, WithGitBinary(binary), WithRetry(3, time.Millisecond)).Commit("not retried"); err == nil {
+		t.Fatal("Commit() error = nil, want the bad object error")
+	}
+	if got := invocations(t, counter); got != "1" {
+		t.Errorf("git commit ran %s times, want 1", got)
+	}
+}
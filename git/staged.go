@@ -0,0 +1,77 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrPathsNotStaged is returned by DiffStagedPaths, along with the diff of the other paths,
+// when some of the requested paths have no staged changes.
+var ErrPathsNotStaged = errors.New("paths have no staged changes")
+
+// errorsNoStagedPaths is returned by DiffStagedPaths when it is called without paths.
+var errorsNoStagedPaths = errors.New("no paths given, use DiffFiles for the whole staged diff")
+
+// DiffStagedPaths returns the staged diff of the given paths only, the same as git diff --cached -- <paths>,
+// so a message can be written for part of what is staged. Paths are relative to the repository root
+// and may name directories. The active range and excludes are ignored, while the diff flags apply.
+// When some paths have no staged changes, the diff of the others is returned with an error wrapping
+// ErrPathsNotStaged that names them, so callers can warn and carry on. At least one path is required.
+func (c *Command) DiffStagedPaths(paths ...string) (string, error) {
+	if len(paths) == 0 {
+		return "", errorsNoStagedPaths
+	}
+
+	ctx := context.Background()
+	specs := make([]string, len(paths))
+	for i, p := range paths {
+		specs[i] = ":(top,literal)" + p
+	}
+
+	args := append([]string{"diff", "--cached", "--name-only", "-z", "--"}, specs...)
+	output, err := c.output(c.gitCmd(ctx, args...))
+	if err != nil {
+		return "", err
+	}
+	staged := splitNUL(string(output))
+	if len(staged) == 0 {
+		return "", ErrNoStagedChanges
+	}
+
+	flags, err := c.diffFlags(ctx)
+	if err != nil {
+		return "", err
+	}
+	args = append([]string{"diff", "--cached"}, flags...)
+	args = append(args, c.renameFlags(false)...)
+	args = append(args, "--")
+	output, err = c.output(c.gitCmd(ctx, append(args, specs...)...))
+	if err != nil {
+		return "", err
+	}
+	diff := string(output)
+
+	var missing []string
+	for _, p := range paths {
+		if !containsPath(staged, p) {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) > 0 {
+		return diff, fmt.Errorf("%w: %s", ErrPathsNotStaged, strings.Join(missing, ", "))
+	}
+	return diff, nil
+}
+
+// containsPath reports whether one of files is p or lies below the directory p.
+func containsPath(files []string, p string) bool {
+	p = strings.TrimSuffix(strings.TrimPrefix(p, "/"), "/")
+	for _, f := range files {
+		if f == p || strings.HasPrefix(f, p+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package git
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDiffStagedPaths(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "README.md", "readme\n", "init")
+	for _, name := range []string{"a.txt", "b.txt", "docs/c.txt"} {
+		writeFile(t, name, name+"\n")
+	}
+	runGit(t, "add", ".")
+	writeFile(t, "unstaged.txt", "unstaged\n")
+
+	g := mustNew(t)
+	diff, err := g.DiffStagedPaths("a.txt", "docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"+++ b/a.txt", "+++ b/docs/c.txt"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("DiffStagedPaths() missing %q:\n%s", want, diff)
+		}
+	}
+	if strings.Contains(diff, "+++ b/b.txt") {
+		t.Errorf("DiffStagedPaths() should not include b.txt:\n%s", diff)
+	}
+
+	diff, err = g.DiffStagedPaths("a.txt", "unstaged.txt")
+	if !errors.Is(err, ErrPathsNotStaged) || !strings.Contains(err.Error(), "unstaged.txt") {
+		t.Errorf("DiffStagedPaths() error = %v, want %v naming unstaged.txt", err, ErrPathsNotStaged)
+	}
+	if !strings.Contains(diff, "+++ b/a.txt") {
+		t.Errorf("DiffStagedPaths() should still return the staged paths:\n%s", diff)
+	}
+
+	if _, err := g.DiffStagedPaths(); !errors.Is(err, errorsNoStagedPaths) {
+		t.Errorf("DiffStagedPaths() error = %v, want %v", err, errorsNoStagedPaths)
+	}
+	if _, err := g.DiffStagedPaths("unstaged.txt"); !errors.Is(err, ErrNoStagedChanges) {
+		t.Errorf("DiffStagedPaths() error = %v, want %v", err, ErrNoStagedChanges)
+	}
+}
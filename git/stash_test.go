@@ -0,0 +1,49 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithStash(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "one\n", "add a")
+	commitFile(t, "go.sum", "sum\n", "add go.sum")
+	writeFile(t, "a.txt", "stashed change\n")
+	writeFile(t, "go.sum", "changed sum\n")
+	runGit(t, "stash", "-q")
+	writeFile(t, "a.txt", "working tree change\n")
+
+	g := mustNew(t, WithStash("stash@{0}"))
+	files, err := g.ChangedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.txt"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("ChangedFiles() = %v, want %v", files, want)
+	}
+
+	diff, err := g.DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "+stashed change") {
+		t.Errorf("DiffFiles() is missing the stashed change:\n%s", diff)
+	}
+	if strings.Contains(diff, "working tree change") || strings.Contains(diff, "go.sum") {
+		t.Errorf("DiffFiles() should only show the stash without excluded files:\n%s", diff)
+	}
+
+	stats, err := g.DiffStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Stats{FilesChanged: 1, Insertions: 1, Deletions: 1}); stats != want {
+		t.Errorf("DiffStats() = %+v, want %+v", stats, want)
+	}
+
+	if _, err := mustNew(t, WithStash("stash@{3}")).ChangedFiles(); err == nil || !strings.Contains(err.Error(), "stash@{3}") {
+		t.Errorf("ChangedFiles() error = %v, want a missing stash error", err)
+	}
+}
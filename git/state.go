@@ -0,0 +1,83 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// State is the operation the repository is in the middle of.
+type State int
+
+const (
+	StateNormal State = iota
+	StateMerging
+	StateRebasing
+	StateCherryPicking
+	StateReverting
+)
+
+// String returns a readable name for the state.
+func (s State) String() string {
+	switch s {
+	case StateMerging:
+		return "merging"
+	case StateRebasing:
+		return "rebasing"
+	case StateCherryPicking:
+		return "cherry-picking"
+	case StateReverting:
+		return "reverting"
+	default:
+		return "normal"
+	}
+}
+
+// stateMarkers maps the files git keeps in the git directory during an operation to the resulting state.
+// A rebase is checked first, since it cherry-picks commits while running.
+var stateMarkers = []struct {
+	name  string
+	state State
+}{
+	{name: "rebase-merge", state: StateRebasing},
+	{name: "rebase-apply", state: StateRebasing},
+	{name: "MERGE_HEAD", state: StateMerging},
+	{name: "CHERRY_PICK_HEAD", state: StateCherryPicking},
+	{name: "REVERT_HEAD", state: StateReverting},
+}
+
+// RepoState reports whether the repository is in the middle of a merge, rebase, cherry-pick or revert,
+// so callers can warn before generating a commit message.
+func (c *Command) RepoState() (State, error) {
+	gitDir, err := c.GitDir()
+	if err != nil {
+		return StateNormal, err
+	}
+
+	for _, m := range stateMarkers {
+		_, err := os.Stat(filepath.Join(gitDir, m.name))
+		if err == nil {
+			return m.state, nil
+		}
+		if !os.IsNotExist(err) {
+			return StateNormal, err
+		}
+	}
+	return StateNormal, nil
+}
+
+// IsDirty reports whether git status --porcelain lists anything: staged, unstaged or untracked changes.
+// Callers can warn that unstaged changes are not part of a message written from the staged diff.
+func (c *Command) IsDirty() (bool, error) {
+	output, err := c.output(c.gitCmd(
+		context.Background(),
+		"status",
+		"--porcelain",
+	))
+	if err != nil {
+		return false, err
+	}
+
+	return strings.TrimSpace(string(output)) != "", nil
+}
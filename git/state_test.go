@@ -0,0 +1,88 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepoState(t *testing.T) {
+	tests := []struct {
+		name   string
+		marker string
+		dir    bool
+		want   State
+	}{
+		{name: "normal", want: StateNormal},
+		{name: "merging", marker: "MERGE_HEAD", want: StateMerging},
+		{name: "rebasing interactive", marker: "rebase-merge", dir: true, want: StateRebasing},
+		{name: "rebasing apply", marker: "rebase-apply", dir: true, want: StateRebasing},
+		{name: "cherry-picking", marker: "CHERRY_PICK_HEAD", want: StateCherryPicking},
+		{name: "reverting", marker: "REVERT_HEAD", want: StateReverting},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := setupRepo(t)
+			if tt.marker != "" {
+				marker := filepath.Join(dir, ".git", tt.marker)
+				if tt.dir {
+					if err := os.Mkdir(marker, 0o755); err != nil {
+						t.Fatal(err)
+					}
+				} else {
+					writeFile(t, marker, "0000000000000000000000000000000000000000\n")
+				}
+			}
+
+			got, err := mustNew(t).RepoState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("RepoState() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDirty(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T)
+		want  bool
+	}{
+		{
+			name:  "clean",
+			setup: func(t *testing.T) {},
+			want:  false,
+		},
+		{
+			name:  "unstaged only",
+			setup: func(t *testing.T) { writeFile(t, "a.txt", "b\n") },
+			want:  true,
+		},
+		{
+			name: "staged only",
+			setup: func(t *testing.T) {
+				writeFile(t, "a.txt", "b\n")
+				runGit(t, "add", "a.txt")
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupRepo(t)
+			commitFile(t, "a.txt", "a\n", "first")
+			tt.setup(t)
+
+			got, err := mustNew(t).IsDirty()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("IsDirty() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
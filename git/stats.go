@@ -0,0 +1,154 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Stats summarizes the size of a diff.
+type Stats struct {
+	FilesChanged int
+	Insertions   int
+	Deletions    int
+}
+
+// DiffStats returns the number of changed files, insertions and deletions
+// over the same range and excludes used by DiffFiles.
+// Binary files are counted as changed files but not as insertions or deletions.
+func (c *Command) DiffStats() (Stats, error) {
+	files, err := c.diffNumstat(context.Background())
+	if err != nil {
+		return Stats{}, err
+	}
+
+	return sumStats(files), nil
+}
+
+// ApproxTokens returns a rough estimate of the number of LLM tokens in the diff returned by DiffFiles,
+// counting one token per four bytes, so callers can decide whether to split the diff first.
+// Real tokenizers differ by model; do not rely on the exact value. It is zero when there are no changes.
+func (c *Command) ApproxTokens() (int, error) {
+	diff, err := c.DiffFiles()
+	if errors.Is(err, ErrNoStagedChanges) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return approxTokens(diff), nil
+}
+
+// approxTokens estimates the number of tokens in s as a quarter of its length, rounded up.
+func approxTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+// fileStat is the number of lines a diff inserts into and deletes from a file.
+type fileStat struct {
+	path       string
+	insertions int
+	deletions  int
+	binary     bool // binary files have no line counts
+}
+
+// sumStats adds up the line counts of files.
+func sumStats(files []fileStat) Stats {
+	stats := Stats{FilesChanged: len(files)}
+	for _, f := range files {
+		stats.Insertions += f.insertions
+		stats.Deletions += f.deletions
+	}
+	return stats
+}
+
+// diffNumstat returns the line counts of the changed files listed by git diff --numstat.
+func (c *Command) diffNumstat(ctx context.Context) ([]fileStat, error) {
+	fields, err := c.diffFields(ctx, "--numstat")
+	if err != nil {
+		return nil, err
+	}
+	files, err := parseNumstat(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.stashRef == "" {
+		return files, nil
+	}
+	// git stash show cannot filter by path itself.
+	var selected []fileStat
+	for _, f := range files {
+		if c.selectsPath(f.path) {
+			selected = append(selected, f)
+		}
+	}
+	return selected, nil
+}
+
+// parseNumstat parses the fields of git diff --numstat -z.
+// Each is "<insertions>\t<deletions>\t<path>", with "-" counts for binary files.
+// Renames and copies leave the path empty and are followed by the old and new paths.
+func parseNumstat(fields []string) ([]fileStat, error) {
+	var files []fileStat
+	for i := 0; i < len(fields); i++ {
+		counts := strings.SplitN(fields[i], "\t", 3)
+		if len(counts) != 3 {
+			return nil, fmt.Errorf("invalid numstat line: %q", fields[i])
+		}
+		f := fileStat{path: counts[2], binary: counts[0] == "-" && counts[1] == "-"}
+		if f.path == "" {
+			if i+2 >= len(fields) {
+				return nil, fmt.Errorf("invalid numstat line: %q", fields[i])
+			}
+			f.path = fields[i+2]
+			i += 2
+		}
+
+		if !f.binary {
+			var err error
+			if f.insertions, err = strconv.Atoi(counts[0]); err != nil {
+				return nil, fmt.Errorf("invalid numstat line: %q", fields[i])
+			}
+			if f.deletions, err = strconv.Atoi(counts[1]); err != nil {
+				return nil, fmt.Errorf("invalid numstat line: %q", fields[i])
+			}
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// BinaryFiles returns the binary files changed over the same range and excludes used by DiffFiles.
+func (c *Command) BinaryFiles() ([]string, error) {
+	binary, err := c.binaryFiles(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(binary))
+	for f := range binary {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// binaryFiles returns the set of changed binary files, which git diff --numstat reports with "-" counts.
+func (c *Command) binaryFiles(ctx context.Context) (map[string]bool, error) {
+	files, err := c.diffNumstat(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	binary := make(map[string]bool)
+	for _, f := range files {
+		if f.binary {
+			binary[f.path] = true
+		}
+	}
+	return binary, nil
+}
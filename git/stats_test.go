@@ -0,0 +1,147 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffStats(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "one\ntwo\nthree\n", "text")
+	commitFile(t, "image.bin", "\x00\x01\x02", "binary")
+
+	writeFile(t, "a.txt", "one\n2\nthree\nfour\n")
+	writeFile(t, "image.bin", "\x00\x03\x04\x05")
+
+	got, err := mustNew(t).DiffStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Stats{FilesChanged: 2, Insertions: 2, Deletions: 1}
+	if got != want {
+		t.Errorf("DiffStats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseNumstat(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    Stats
+		wantErr bool
+	}{
+		{
+			name: "empty",
+			want: Stats{},
+		},
+		{
+			name:   "text and binary",
+			output: "3\t1\tmain.go\x00-\t-\tlogo.png\x00",
+			want:   Stats{FilesChanged: 2, Insertions: 3, Deletions: 1},
+		},
+		{
+			name:   "rename",
+			output: "2\t0\t\x00old name.go\x00new name.go\x001\t1\tcaf\u00e9.txt\x00",
+			want:   Stats{FilesChanged: 2, Insertions: 3, Deletions: 1},
+		},
+		{
+			name:    "malformed",
+			output:  "x\t1\tmain.go\x00",
+			wantErr: true,
+		},
+		{
+			name:    "truncated rename",
+			output:  "2\t0\t\x00old.go\x00",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := parseNumstat(splitNUL(tt.output))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseNumstat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := sumStats(files); got != tt.want {
+				t.Errorf("parseNumstat() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBinaryFiles(t *testing.T) {
+	setupRepo(t)
+	png := "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+	commitFile(t, "logo.png", png, "add logo")
+	commitFile(t, "notes.txt", "one\n", "add notes")
+
+	writeFile(t, "logo.png", png+"\x00\x01")
+	writeFile(t, "notes.txt", "two\n")
+
+	g := mustNew(t, WithOmitBinary(true))
+	diff, err := g.DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "+two") || strings.Contains(diff, "logo.png") {
+		t.Errorf("DiffFiles() = %q, want only the notes.txt patch", diff)
+	}
+
+	files, err := g.BinaryFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"logo.png"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("BinaryFiles() = %v, want %v", files, want)
+	}
+
+	diff, err = mustNew(t).DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "Binary files a/logo.png and b/logo.png differ") {
+		t.Errorf("DiffFiles() = %q, want the binary patch without WithOmitBinary", diff)
+	}
+}
+
+func TestApproxTokens(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "one\n", "init")
+
+	c := mustNew(t)
+	got, err := c.ApproxTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 0 {
+		t.Errorf("ApproxTokens() without changes = %d, want 0", got)
+	}
+
+	writeFile(t, "a.txt", "one\ntwo\n")
+	small, err := c.ApproxTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if small <= 0 {
+		t.Fatalf("ApproxTokens() = %d, want > 0", small)
+	}
+
+	writeFile(t, "a.txt", "one\n"+strings.Repeat("a longer line of text\n", 100))
+	large, err := c.ApproxTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if large <= small*10 {
+		t.Errorf("ApproxTokens() = %d for a large diff, want much more than %d", large, small)
+	}
+}
+
+func TestApproxTokensCount(t *testing.T) {
+	tests := map[string]int{"": 0, "a": 1, "abcd": 1, "abcde": 2}
+	for s, want := range tests {
+		if got := approxTokens(s); got != want {
+			t.Errorf("approxTokens(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
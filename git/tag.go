@@ -0,0 +1,116 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ErrNoMatchingTags is returned by LatestTwoTags, and by diffs comparing the latest two tags,
+// when fewer than two tags match WithDiffTagPrefix and WithTagPattern.
+var ErrNoMatchingTags = errors.New("fewer than two matching tags")
+
+// TagPair is the two latest tags selected by WithDiffTagPrefix and WithTagPattern.
+type TagPair struct {
+	Older string
+	Newer string
+}
+
+// LatestTwoTags returns the two latest tags selected by WithDiffTagPrefix and WithTagPattern.
+// Unlike IsDiffTag, it returns an error when fewer than two tags match.
+func (c *Command) LatestTwoTags() (TagPair, error) {
+	return c.latestTagPair(context.Background())
+}
+
+// latestTagPair returns the two latest tags selected by matchTag, or ErrNoMatchingTags naming how many matched.
+func (c *Command) latestTagPair(ctx context.Context) (TagPair, error) {
+	tags, err := c.latestTwoTags(ctx)
+	if err != nil {
+		return TagPair{}, err
+	}
+	if len(tags) < 2 {
+		return TagPair{}, fmt.Errorf("%w: found %d", ErrNoMatchingTags, len(tags))
+	}
+	return TagPair{Older: tags[1], Newer: tags[0]}, nil
+}
+
+// IsDiffTag judge whether to compare the differences between the latest two tags
+func (c *Command) IsDiffTag() (is bool, tagNew, tagOld string) {
+	return c.isDiffTag(context.Background())
+}
+
+func (c *Command) isDiffTag(ctx context.Context) (is bool, tagNew, tagOld string) {
+	if c.diffTagPrefix != "" || c.tagPattern != "" {
+		is = true
+		tags, err := c.latestTwoTags(ctx)
+		if err != nil {
+			return false, "", ""
+		}
+		if len(tags) == 2 {
+			tagNew, tagOld = tags[0], tags[1]
+		}
+	}
+	return
+}
+
+// MatchingTags returns every tag selected by WithDiffTagPrefix and WithTagPattern, newest first
+// according to WithTagSort, so a user can pick the range to compare. Without either option, all tags are returned.
+// The result is empty when no tag matches.
+func (c *Command) MatchingTags() ([]string, error) {
+	return c.sortedTags(context.Background(), 0)
+}
+
+// latestTwoTags returns the two latest tags selected by matchTag, newest first, according to tagSort.
+func (c *Command) latestTwoTags(ctx context.Context) ([]string, error) {
+	return c.sortedTags(ctx, 2)
+}
+
+// sortedTags returns at most n tags selected by matchTag, or all of them if n is zero,
+// newest first according to tagSort.
+func (c *Command) sortedTags(ctx context.Context, n int) ([]string, error) {
+	output, err := c.output(c.gitCmd(
+		ctx,
+		"tag",
+		"--sort=-"+c.tagSort,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	return c.filterTags(string(output), n), nil
+}
+
+// filterTags returns at most n tags from the newline-separated list selected by matchTag,
+// or all of them if n is zero, keeping their order.
+func (c *Command) filterTags(list string, n int) []string {
+	var tags []string
+	for _, tag := range strings.Split(list, "\n") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || !c.matchTag(tag) {
+			continue
+		}
+		tags = append(tags, tag)
+		if len(tags) == n {
+			break
+		}
+	}
+	return tags
+}
+
+// matchTag reports whether tag starts with diffTagPrefix and matches tagPattern,
+// as a regular expression if tagRegexp is set and as a glob otherwise.
+func (c *Command) matchTag(tag string) bool {
+	if !strings.HasPrefix(tag, c.diffTagPrefix) {
+		return false
+	}
+	switch {
+	case c.tagRegexp != nil:
+		return c.tagRegexp.MatchString(tag)
+	case c.tagPattern != "":
+		matched, _ := path.Match(c.tagPattern, tag)
+		return matched
+	}
+	return true
+}
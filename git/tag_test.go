@@ -0,0 +1,242 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// tagAt creates an annotated tag whose creation date is the given unix timestamp,
+// so tag sort order does not depend on how fast the test runs.
+func tagAt(t *testing.T, name string, timestamp int) {
+	t.Helper()
+
+	cmd := exec.Command("git", "tag", "-a", "-m", name, name)
+	cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE=@"+strconv.Itoa(timestamp)+" +0000")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git tag %s: %v\n%s", name, err, output)
+	}
+}
+
+func TestFilterTags(t *testing.T) {
+	list := "v1.2.0\nrelease-3\nv1.1.0\r\nv1.0.0\n"
+
+	tests := []struct {
+		name   string
+		prefix string
+		want   []string
+	}{
+		{
+			name:   "two latest",
+			prefix: "v",
+			want:   []string{"v1.2.0", "v1.1.0"},
+		},
+		{
+			name:   "single match",
+			prefix: "release",
+			want:   []string{"release-3"},
+		},
+		{
+			name:   "no match",
+			prefix: "beta",
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mustNew(t, WithDiffTagPrefix(tt.prefix)).filterTags(list, 2); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDiffTag(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	tagAt(t, "v1.0.0", 1000)
+	commitFile(t, "b.txt", "b\n", "second")
+	tagAt(t, "other", 3000)
+	tagAt(t, "v1.1.0", 2000)
+
+	is, tagNew, tagOld := mustNew(t, WithDiffTagPrefix("v")).IsDiffTag()
+	if !is || tagNew != "v1.1.0" || tagOld != "v1.0.0" {
+		t.Errorf("IsDiffTag() = %v, %q, %q, want true, v1.1.0, v1.0.0", is, tagNew, tagOld)
+	}
+}
+
+func TestWithTagToHead(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "a")
+	runGit(t, "tag", "v1.0.0")
+	commitFile(t, "b.txt", "b\n", "b")
+	commitFile(t, "c.txt", "c\n", "c")
+
+	files, err := mustNew(t, WithTagToHead("v1.0.0")).ChangedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"b.txt", "c.txt"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("ChangedFiles() = %v, want %v", files, want)
+	}
+
+	_, err = mustNew(t, WithTagToHead("v9.9.9")).ChangedFiles()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || !strings.Contains(err.Error(), "refs/tags/v9.9.9") {
+		t.Errorf("ChangedFiles() error = %v, want a wrapped git error naming the tag", err)
+	}
+}
+
+func TestWithTagSort(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	tagAt(t, "v1.10.0", 1000)
+	tagAt(t, "v1.0.0", 2000)
+	tagAt(t, "v1.2.0", 3000)
+
+	is, tagNew, tagOld := mustNew(t, WithDiffTagPrefix("v"), WithTagSort("version:refname")).IsDiffTag()
+	if !is || tagNew != "v1.10.0" || tagOld != "v1.2.0" {
+		t.Errorf("IsDiffTag() = %v, %q, %q, want true, v1.10.0, v1.2.0", is, tagNew, tagOld)
+	}
+
+	is, tagNew, tagOld = mustNew(t, WithDiffTagPrefix("v")).IsDiffTag()
+	if !is || tagNew != "v1.2.0" || tagOld != "v1.0.0" {
+		t.Errorf("IsDiffTag() = %v, %q, %q, want true, v1.2.0, v1.0.0", is, tagNew, tagOld)
+	}
+
+	if _, err := New(WithTagSort("-version:refname")); !errors.Is(err, errorsInvalidTagSort) {
+		t.Errorf("New() error = %v, want %v", err, errorsInvalidTagSort)
+	}
+}
+
+func TestWithTagPattern(t *testing.T) {
+	list := "release/2.0\nv1.10.0\nv1.2.0-rc1\nrelease-candidate\nv1.2.0\nrelease/1.0\n"
+
+	tests := []struct {
+		name    string
+		pattern string
+		isRegex bool
+		want    []string
+	}{
+		{
+			name:    "glob",
+			pattern: "release/*",
+			want:    []string{"release/2.0", "release/1.0"},
+		},
+		{
+			name:    "regex",
+			pattern: `^v\d+\.\d+\.\d+$`,
+			isRegex: true,
+			want:    []string{"v1.10.0", "v1.2.0"},
+		},
+		{
+			name:    "glob is anchored",
+			pattern: "v1.2.0",
+			want:    []string{"v1.2.0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mustNew(t, WithTagPattern(tt.pattern, tt.isRegex)).filterTags(list, 2); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, tt := range []struct {
+		pattern string
+		isRegex bool
+	}{
+		{pattern: "release/[", isRegex: false},
+		{pattern: "v(", isRegex: true},
+	} {
+		if _, err := New(WithTagPattern(tt.pattern, tt.isRegex)); !errors.Is(err, errorsInvalidTagPattern) {
+			t.Errorf("New(%q) error = %v, want %v", tt.pattern, err, errorsInvalidTagPattern)
+		}
+	}
+}
+
+func TestLatestTwoTags(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	g := mustNew(t, WithDiffTagPrefix("v"))
+
+	if _, err := g.LatestTwoTags(); !errors.Is(err, ErrNoMatchingTags) {
+		t.Errorf("zero tags: error = %v, want %v", err, ErrNoMatchingTags)
+	}
+	if _, err := g.DiffFiles(); !errors.Is(err, ErrNoMatchingTags) {
+		t.Errorf("zero tags: DiffFiles() error = %v, want %v", err, ErrNoMatchingTags)
+	}
+
+	tagAt(t, "v1.0.0", 1000)
+	tagAt(t, "other", 3000)
+	if _, err := g.LatestTwoTags(); !errors.Is(err, ErrNoMatchingTags) || !strings.Contains(err.Error(), "found 1") {
+		t.Errorf("one tag: error = %v, want %v naming one tag", err, ErrNoMatchingTags)
+	}
+	if _, err := g.DiffFiles(); !errors.Is(err, ErrNoMatchingTags) {
+		t.Errorf("one tag: DiffFiles() error = %v, want %v", err, ErrNoMatchingTags)
+	}
+
+	tagAt(t, "v1.1.0", 2000)
+	got, err := g.LatestTwoTags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (TagPair{Older: "v1.0.0", Newer: "v1.1.0"}); got != want {
+		t.Errorf("LatestTwoTags() = %+v, want %+v", got, want)
+	}
+}
+
+func TestMatchingTags(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	g := mustNew(t, WithDiffTagPrefix("v"))
+
+	got, err := g.MatchingTags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("MatchingTags() without tags = %q, want none", got)
+	}
+
+	tagAt(t, "v1.0.0", 1000)
+	tagAt(t, "v1.2.0", 3000)
+	tagAt(t, "other", 4000)
+	tagAt(t, "v1.1.0", 2000)
+	got, err = g.MatchingTags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"v1.2.0", "v1.1.0", "v1.0.0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MatchingTags() = %q, want %q", got, want)
+	}
+
+	got, err = mustNew(t).MatchingTags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"other", "v1.2.0", "v1.1.0", "v1.0.0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MatchingTags() without a prefix = %q, want %q", got, want)
+	}
+}
+
+func TestDiffCommandString(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	tagAt(t, "v1.0.0", 1000)
+	commitFile(t, "b.txt", "b\n", "second")
+	tagAt(t, "v1.1.0", 2000)
+
+	got := mustNew(t, WithDiffTagPrefix("v"), WithExcludeList([]string{"docs/**"})).DiffCommandString()
+	want := "git diff --ignore-all-space --diff-algorithm=minimal --unified=0 --submodule=short --color=never v1.0.0 v1.1.0 " +
+		"':(exclude,top)package-lock.json' ':(exclude,top)pnpm-lock.yaml' ':(exclude,top)*.lock' " +
+		"':(exclude,top)go.sum' ':(exclude,top,glob)docs/**'"
+	if got != want {
+		t.Errorf("DiffCommandString() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,58 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithTimeout(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "a.txt", "b\n")
+
+	g := mustNew(t,
+		WithGitBinary(fakeGit(t, `[ "$1" = diff ] && sleep 5`)),
+		WithTimeout(100*time.Millisecond),
+	)
+
+	start := time.Now()
+	_, err := g.DiffFiles()
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "git diff") {
+		t.Errorf("DiffFiles() error = %v, want %v naming git diff", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("DiffFiles() took %v, want the timeout to stop git", elapsed)
+	}
+
+	if _, err := mustNew(t, WithTimeout(10*time.Second)).DiffFiles(); err != nil {
+		t.Errorf("DiffFiles() error = %v, want none", err)
+	}
+}
+
+func TestWithTimeoutStartsWithGit(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "a.txt", "b\n")
+
+	g := mustNew(t, WithTimeout(200*time.Millisecond))
+	cmd, err := g.diffFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	if _, err := g.output(cmd); err != nil {
+		t.Errorf("output() error = %v, want the timeout to count from when git starts", err)
+	}
+
+	g = mustNew(t,
+		WithGitBinary(fakeGit(t, `[ "$1" = diff ] && exec sleep 5`)),
+		WithTimeout(100*time.Millisecond),
+	)
+	var out strings.Builder
+	if err := g.WriteDiff(&out); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WriteDiff() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
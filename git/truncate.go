@@ -0,0 +1,60 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// truncateDiff cuts diff to at most limit bytes and appends a notice with the number of bytes omitted.
+// It prefers to cut before a file or hunk header, then at the end of a line,
+// and never in the middle of a UTF-8 rune. A limit of zero or less disables truncation.
+func truncateDiff(diff string, limit int) string {
+	if limit <= 0 || len(diff) <= limit {
+		return diff
+	}
+
+	cut := -1
+	for i := 0; i < len(diff) && i <= limit; {
+		if i > 0 && (strings.HasPrefix(diff[i:], "@@") || strings.HasPrefix(diff[i:], "diff --git ")) {
+			cut = i
+		}
+		end := strings.IndexByte(diff[i:], '\n')
+		if end == -1 {
+			break
+		}
+		i += end + 1
+	}
+
+	if cut == -1 {
+		// A single hunk is larger than limit, so fall back to the last complete line.
+		cut = strings.LastIndexByte(diff[:limit], '\n') + 1
+	}
+	if cut == 0 {
+		cut = limit
+		for cut > 0 && !utf8.RuneStart(diff[cut]) {
+			cut--
+		}
+	}
+
+	return fmt.Sprintf("%s\n... [diff truncated, %d bytes omitted]", diff[:cut], len(diff)-cut)
+}
+
+// omitLargePatches replaces each per-file patch of diff larger than limit bytes with its "diff --git" header
+// and a placeholder, so one huge file cannot crowd out the others. A limit of zero or less disables it.
+func omitLargePatches(diff string, limit int) string {
+	if limit <= 0 || len(diff) <= limit {
+		return diff
+	}
+
+	var b strings.Builder
+	for _, patch := range splitOnHeaders(diff) {
+		if len(patch) <= limit {
+			b.WriteString(patch)
+			continue
+		}
+		header := patch[:strings.IndexByte(patch, '\n')+1]
+		fmt.Fprintf(&b, "%s[file omitted: %d bytes changed]\n", header, len(patch))
+	}
+	return b.String()
+}
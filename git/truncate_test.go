@@ -0,0 +1,120 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateDiff(t *testing.T) {
+	first := "diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-a\n+b\n"
+	second := "diff --git a/b.txt b/b.txt\n--- a/b.txt\n+++ b/b.txt\n@@ -1 +1 @@\n-c\n+d\n"
+	diff := first + second
+
+	tests := []struct {
+		name  string
+		diff  string
+		limit int
+		want  string
+	}{
+		{name: "disabled", diff: diff, limit: 0, want: diff},
+		{name: "under limit", diff: diff, limit: len(diff), want: diff},
+		{
+			name:  "file boundary",
+			diff:  diff,
+			limit: len(first) + 10,
+			want:  first + "\n... [diff truncated, 69 bytes omitted]",
+		},
+		{
+			name:  "hunk boundary",
+			diff:  diff,
+			limit: len(first) - 3,
+			want:  "diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n\n... [diff truncated, 87 bytes omitted]",
+		},
+		{
+			name:  "line boundary",
+			diff:  "@@ -1 +1 @@\n-a\n+b\n",
+			limit: 16,
+			want:  "@@ -1 +1 @@\n-a\n\n... [diff truncated, 3 bytes omitted]",
+		},
+		{
+			name:  "rune boundary",
+			diff:  "+héllo",
+			limit: 3,
+			want:  "+h\n... [diff truncated, 5 bytes omitted]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateDiff(tt.diff, tt.limit)
+			if got != tt.want {
+				t.Errorf("truncateDiff() = %q, want %q", got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("truncateDiff() = %q is not valid UTF-8", got)
+			}
+		})
+	}
+}
+
+func TestWithMaxDiffBytes(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "one\n", "add a")
+	commitFile(t, "b.txt", "two\n", "add b")
+	writeFile(t, "a.txt", "uno\n")
+	writeFile(t, "b.txt", "dos\n")
+
+	full, err := mustNew(t).DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := mustNew(t, WithMaxDiffBytes(len(full))).DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != full {
+		t.Errorf("under limit: got %q, want %q", got, full)
+	}
+
+	got, err = mustNew(t, WithMaxDiffBytes(len(full)-1)).DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, notice, found := strings.Cut(got, "\n... [diff truncated, ")
+	if !found {
+		t.Fatalf("over limit: got %q, want a truncation notice", got)
+	}
+	if len(kept) >= len(full) || !strings.HasPrefix(full, kept) {
+		t.Errorf("over limit: kept %q, want a prefix of %q", kept, full)
+	}
+	if want := fmt.Sprintf("%d bytes omitted]", len(full)-len(kept)); notice != want {
+		t.Errorf("over limit: notice = %q, want %q", notice, want)
+	}
+}
+
+func TestWithMaxFilePatchBytes(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "small.txt", "a\n", "add small")
+	commitFile(t, "vendor.min.js", "a\n", "add vendor")
+	writeFile(t, "small.txt", "b\n")
+	writeFile(t, "vendor.min.js", strings.Repeat("x", 4096)+"\n")
+
+	diff, err := mustNew(t, WithMaxFilePatchBytes(1024)).DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patches := splitPatches(diff)
+	if len(patches) != 2 {
+		t.Fatalf("DiffFiles() has %d patches, want 2:\n%s", len(patches), diff)
+	}
+	if !strings.Contains(patches[0].patch, "+b") {
+		t.Errorf("small.txt patch = %q, want it in full", patches[0].patch)
+	}
+	if p := patches[1]; p.path != "vendor.min.js" || !strings.HasPrefix(p.patch, "diff --git a/vendor.min.js b/vendor.min.js\n[file omitted: ") ||
+		!strings.HasSuffix(p.patch, " bytes changed]\n") || strings.Contains(p.patch, "xxx") {
+		t.Errorf("vendor.min.js patch = %q, want a placeholder", p.patch)
+	}
+}
@@ -0,0 +1,40 @@
+package git
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+// untrackedFiles returns the untracked files selected by the include and exclude lists,
+// skipping files ignored by .gitignore.
+func (c *Command) untrackedFiles(ctx context.Context) ([]string, error) {
+	args := append([]string{"ls-files", "--others", "--exclude-standard", "-z", "--"}, c.pathspecs()...)
+	output, err := c.output(c.gitCmd(ctx, args...))
+	if err != nil {
+		return nil, err
+	}
+	return splitNUL(string(output)), nil
+}
+
+// untrackedDiff diffs each of files against an empty file, so they show as new files,
+// without adding them to the index. It uses the same diff flags as DiffFiles, such as --color=never.
+func (c *Command) untrackedDiff(ctx context.Context, files []string) (string, error) {
+	flags, err := c.diffFlags(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, f := range files {
+		args := append([]string{"diff", "--no-index"}, flags...)
+		args = append(args, "--", os.DevNull, f)
+		// git diff --no-index exits with status 1 when the files differ, which diffOutput tolerates.
+		output, err := c.diffOutput(c.gitCmd(ctx, args...))
+		if err != nil {
+			return "", err
+		}
+		b.Write(output)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,69 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithIncludeUntracked(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, ".gitignore", "*.log\n", "ignore logs")
+	writeFile(t, "new.txt", "hello\n")
+	writeFile(t, "debug.log", "ignored\n")
+
+	if _, err := mustNew(t).DiffFiles(); err == nil {
+		t.Fatal("DiffFiles() without WithIncludeUntracked: want an error for an untracked-only change")
+	}
+
+	g := mustNew(t, WithIncludeUntracked(true))
+	files, err := g.ChangedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"new.txt"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("ChangedFiles() = %v, want %v", files, want)
+	}
+
+	diff, err := g.DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"new file mode", "--- /dev/null", "+++ b/new.txt", "+hello"} {
+		if !strings.Contains(diff, s) {
+			t.Errorf("DiffFiles() is missing %q:\n%s", s, diff)
+		}
+	}
+
+	if status := runGit(t, "status", "--porcelain"); status != "?? new.txt" {
+		t.Errorf("git status = %q, want new.txt to stay untracked", status)
+	}
+
+	// Changes to tracked files take priority over untracked files.
+	writeFile(t, ".gitignore", "*.log\n*.tmp\n")
+	files, err = g.ChangedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{".gitignore"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("ChangedFiles() = %v, want %v", files, want)
+	}
+}
+
+func TestWithIncludeUntrackedColor(t *testing.T) {
+	setupRepo(t)
+	runGit(t, "config", "color.diff", "always")
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "new.txt", "hello  world\n")
+
+	diff, err := mustNew(t, WithIncludeUntracked(true), WithDiffUnified(0)).DiffFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(diff, "\x1b[") {
+		t.Errorf("DiffFiles() = %q, want no ANSI escapes", diff)
+	}
+	if !strings.Contains(diff, "@@ -0,0 +1 @@\n+hello  world\n") {
+		t.Errorf("DiffFiles() =\n%s\nwant the untracked file as an added line", diff)
+	}
+}
@@ -0,0 +1,93 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var (
+	errorsInvalidGitVersion = errors.New("invalid git version")
+	errorsGitTooOld         = errors.New("git version is too old")
+)
+
+// gitVersionPattern matches the version number in git --version output, ignoring suffixes
+// such as " (Apple Git-143)" or ".windows.1". A missing patch number reads as zero.
+var gitVersionPattern = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// Minimum git versions of the flags gated by requireGitVersion.
+const (
+	functionContextVersion = "1.7.8"
+	submoduleDiffVersion   = "2.11.0"
+	commitTrailerVersion   = "2.32.0"
+)
+
+// GitVersion returns the version of the configured git executable, as reported by git --version.
+func (c *Command) GitVersion() (major, minor, patch int, err error) {
+	return c.gitVersion(context.Background())
+}
+
+func (c *Command) gitVersion(ctx context.Context) (major, minor, patch int, err error) {
+	output, err := c.output(c.gitCmd(ctx, "--version"))
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return parseGitVersion(string(output))
+}
+
+// RequireGitVersion returns an error naming both versions when the configured git executable
+// is older than min, such as "2.11.0" or "2.11".
+func (c *Command) RequireGitVersion(min string) error {
+	return c.requireGitVersion(context.Background(), min)
+}
+
+func (c *Command) requireGitVersion(ctx context.Context, min string) error {
+	want, err := parseVersionNumbers(min)
+	if err != nil {
+		return err
+	}
+	major, minor, patch, err := c.gitVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	got := [3]int{major, minor, patch}
+	for i := range got {
+		if got[i] > want[i] {
+			return nil
+		}
+		if got[i] < want[i] {
+			return fmt.Errorf("%w: %d.%d.%d, want at least %s", errorsGitTooOld, major, minor, patch, min)
+		}
+	}
+	return nil
+}
+
+// parseGitVersion parses git --version output, such as "git version 2.39.2 (Apple Git-143)".
+func parseGitVersion(output string) (major, minor, patch int, err error) {
+	v, err := parseVersionNumbers(output)
+	return v[0], v[1], v[2], err
+}
+
+// parseVersionNumbers returns the first major.minor[.patch] version number found in s.
+func parseVersionNumbers(s string) ([3]int, error) {
+	m := gitVersionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return [3]int{}, fmt.Errorf("%w: %q", errorsInvalidGitVersion, s)
+	}
+
+	var v [3]int
+	for i, part := range m[1:] {
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return [3]int{}, fmt.Errorf("%w: %q", errorsInvalidGitVersion, s)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
@@ -0,0 +1,72 @@
+package git
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseGitVersion(t *testing.T) {
+	tests := []struct {
+		output  string
+		want    [3]int
+		wantErr bool
+	}{
+		{output: "git version 2.39.5\n", want: [3]int{2, 39, 5}},
+		{output: "git version 2.39.2 (Apple Git-143)\n", want: [3]int{2, 39, 2}},
+		{output: "git version 2.43.0.windows.1\n", want: [3]int{2, 43, 0}},
+		{output: "git version 2.34.1.vfs.0.0\n", want: [3]int{2, 34, 1}},
+		{output: "git version 2.45\n", want: [3]int{2, 45, 0}},
+		{output: "not git\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		major, minor, patch, err := parseGitVersion(tt.output)
+		if tt.wantErr {
+			if !errors.Is(err, errorsInvalidGitVersion) {
+				t.Errorf("parseGitVersion(%q) error = %v, want %v", tt.output, err, errorsInvalidGitVersion)
+			}
+			continue
+		}
+		if got := [3]int{major, minor, patch}; err != nil || got != tt.want {
+			t.Errorf("parseGitVersion(%q) = %v, %v, want %v", tt.output, got, err, tt.want)
+		}
+	}
+}
+
+func TestRequireGitVersion(t *testing.T) {
+	setupRepo(t)
+	commitFile(t, "a.txt", "a\n", "first")
+	writeFile(t, "a.txt", "b\n")
+
+	binary := fakeGit(t, `[ "$1" = --version ] && echo "git version 2.10.1 (Apple Git-143)" && exit 0`)
+	g := mustNew(t, WithGitBinary(binary))
+	for _, min := range []string{"2.10", "2.10.1", "1.9.9"} {
+		if err := g.RequireGitVersion(min); err != nil {
+			t.Errorf("RequireGitVersion(%q) error = %v, want none", min, err)
+		}
+	}
+	for _, min := range []string{"2.10.2", "2.11", "3.0"} {
+		if err := g.RequireGitVersion(min); !errors.Is(err, errorsGitTooOld) {
+			t.Errorf("RequireGitVersion(%q) error = %v, want %v", min, err, errorsGitTooOld)
+		}
+	}
+
+	if _, err := mustNew(t, WithGitBinary(binary), WithSubmodules("diff")).DiffFiles(); !errors.Is(err, errorsGitTooOld) {
+		t.Errorf("DiffFiles() error = %v, want %v", err, errorsGitTooOld)
+	}
+	if _, err := mustNew(t, WithGitBinary(binary)).DiffFiles(); err != nil {
+		t.Errorf("DiffFiles() error = %v, want none without gated flags", err)
+	}
+
+	runGit(t, "add", "a.txt")
+	g = mustNew(t, WithGitBinary(binary), WithCoAuthors([]string{"Jane Doe <jane@example.com>"}))
+	if _, err := g.Commit("update a"); !errors.Is(err, errorsGitTooOld) {
+		t.Errorf("Commit() error = %v, want %v", err, errorsGitTooOld)
+	}
+	g = mustNew(t, WithGitBinary(binary), WithExtraTrailers(map[string]string{"Refs": "#1"}))
+	if _, err := g.CommitWithBody("update a", "body"); !errors.Is(err, errorsGitTooOld) {
+		t.Errorf("CommitWithBody() error = %v, want %v", err, errorsGitTooOld)
+	}
+	if status := runGit(t, "status", "--porcelain"); status != "M  a.txt" {
+		t.Errorf("git status = %q, want a.txt still staged", status)
+	}
+}
@@ -4,8 +4,8 @@ import (
 	"bytes"
 	"embed"
 	"fmt"
-	"html/template"
 	"io/fs"
+	"text/template"
 )
 
 // Data defines a custom type for the template data.
@@ -31,8 +31,17 @@ func NewTemplateByString(format string, data map[string]interface{}) (string, er
 	return tpl.String(), nil
 }
 
+// MessageData lists the fields a commit message template rendered with RenderTemplate can use,
+// e.g. {{.Branch}} or {{.FilesChanged}}.
+type MessageData struct {
+	FilesChanged int    // number of files in the diff
+	Additions    int    // number of added lines
+	Deletions    int    // number of deleted lines
+	Branch       string // current branch, which often carries a ticket ID
+}
+
 // processTemplate processes the template with the given name and data.
-func processTemplate(name string, data map[string]interface{}) (*bytes.Buffer, error) {
+func processTemplate(name string, data interface{}) (*bytes.Buffer, error) {
 	t, ok := templates[name]
 	if !ok {
 		return nil, fmt.Errorf("template %s not found", name)
@@ -59,6 +68,16 @@ func GetTemplateByBytes(name string, data map[string]interface{}) ([]byte, error
 	return tpl.Bytes(), err
 }
 
+// RenderTemplate formats a message from the loaded template with the given name.
+// data is usually a MessageData, but any value the template can read from works.
+func RenderTemplate(name string, data any) (string, error) {
+	tpl, err := processTemplate(name, data)
+	if err != nil {
+		return "", err
+	}
+	return tpl.String(), nil
+}
+
 // LoadTemplates loads all the templates found in the templates directory.
 func LoadTemplates(files embed.FS) error {
 	if templates == nil {
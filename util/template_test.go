@@ -1,8 +1,8 @@
 package util
 
 import (
-	"html/template"
 	"testing"
+	"text/template"
 )
 
 func TestNewTemplateByString(t *testing.T) {
@@ -69,3 +69,37 @@ func TestProcessTemplate(t *testing.T) {
 		t.Errorf("Unexpected output. Got: %v, Want: %v", buf.String(), expected)
 	}
 }
+
+func TestRenderTemplate(t *testing.T) {
+	tmpl, err := template.New("message.tmpl").Parse("{{.Branch}}: update {{.FilesChanged}} files (+{{.Additions}} -{{.Deletions}})")
+	if err != nil {
+		t.Fatal(err)
+	}
+	templates = map[string]*template.Template{"message.tmpl": tmpl}
+
+	got, err := RenderTemplate("message.tmpl", MessageData{
+		FilesChanged: 3,
+		Additions:    10,
+		Deletions:    2,
+		Branch:       "feature/ABC-123",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "feature/ABC-123: update 3 files (+10 -2)"; got != want {
+		t.Errorf("RenderTemplate() = %q, want %q", got, want)
+	}
+
+	// Commit messages are plain text, so nothing may be HTML-escaped.
+	got, err = RenderTemplate("message.tmpl", MessageData{Branch: "fix: handle o'brien & <c++>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "fix: handle o'brien & <c++>: update 0 files (+0 -0)"; got != want {
+		t.Errorf("RenderTemplate() = %q, want %q", got, want)
+	}
+
+	if _, err := RenderTemplate("missing.tmpl", MessageData{}); err == nil {
+		t.Error("RenderTemplate() error = nil, want template not found")
+	}
+}